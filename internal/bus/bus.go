@@ -0,0 +1,219 @@
+// Package bus provides transport agnostic event bus
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/dlq"
+)
+
+const maxAttempts = 3
+
+// Envelope event with its metadata
+type Envelope struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID uuid.UUID       `json:"aggregateID"`
+	Version     int             `json:"version"`
+	Timestamp   time.Time       `json:"timestamp"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// Message envelope delivered from topic with its position in the topic
+type Message struct {
+	Envelope
+	Position string
+}
+
+// Handler processes delivered message, failed messages are retried and then dead-lettered
+type Handler func(ctx context.Context, message *Message) error
+
+// SubscribeOptions subscription settings
+type SubscribeOptions struct {
+	// Group consumers of the same group share messages, without group every subscriber gets all messages
+	Group    string
+	Consumer string
+	// MinIdle messages not acknowledged by other group consumers for that long are taken over
+	MinIdle time.Duration
+	// From position after which fan-out subscription starts, new messages only by default
+	From string
+	// DeadLetter receives messages which cannot be decoded or handled
+	DeadLetter dlq.DeadLetter
+	// Status receives subscription health
+	Status *Status
+}
+
+// Publisher publishes events to topics
+type Publisher interface {
+	Publish(ctx context.Context, topic string, envelope *Envelope) (string, error)
+	PublishRaw(ctx context.Context, topic string, fields map[string]string) (string, error)
+}
+
+// Subscriber delivers topic messages to handler until ctx is canceled
+type Subscriber interface {
+	Subscribe(ctx context.Context, topic string, opts SubscribeOptions, handler Handler)
+}
+
+// RedriveGroupField field of redriven dead-lettered message naming the only consumer group which handles it,
+// other groups already handled the original message. Empty value targets subscribers without group.
+const RedriveGroupField = "redriveGroup"
+
+// Replayer reads messages already published to topic
+type Replayer interface {
+	// Range returns up to count messages published after position, undecodable messages are skipped
+	// and so are redriven ones, which are copies of messages published before
+	Range(ctx context.Context, topic, after string, count int64) ([]*Message, error)
+}
+
+// Bus event bus interface
+type Bus interface {
+	Publisher
+	Subscriber
+	Replayer
+}
+
+type permanentError struct {
+	err error
+}
+
+func (p permanentError) Error() string {
+	return p.err.Error()
+}
+
+func (p permanentError) Unwrap() error {
+	return p.err
+}
+
+// Permanent marks handler error which must not be retried
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Encode converts envelope to transport fields
+func Encode(envelope *Envelope) map[string]string {
+	return map[string]string{
+		"id":          envelope.ID.String(),
+		"type":        envelope.Type,
+		"aggregateID": envelope.AggregateID.String(),
+		"version":     strconv.Itoa(envelope.Version),
+		"timestamp":   envelope.Timestamp.UTC().Format(time.RFC3339Nano),
+		"payload":     string(envelope.Payload),
+	}
+}
+
+// Decode converts transport fields to envelope
+func Decode(fields map[string]string) (*Envelope, error) {
+	if _, ok := fields["type"]; !ok {
+		return decodeLegacy(fields)
+	}
+	var envelope Envelope
+	var err error
+	envelope.Type = fields["type"]
+	envelope.ID, err = uuid.Parse(fields["id"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid event id: %v", err)
+	}
+	envelope.AggregateID, err = uuid.Parse(fields["aggregateID"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid aggregate id: %v", err)
+	}
+	envelope.Version, err = strconv.Atoi(fields["version"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid version: %v", err)
+	}
+	envelope.Timestamp, err = time.Parse(time.RFC3339Nano, fields["timestamp"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp: %v", err)
+	}
+	if !json.Valid([]byte(fields["payload"])) {
+		return nil, errors.New("payload is not json")
+	}
+	envelope.Payload = json.RawMessage(fields["payload"])
+	return &envelope, nil
+}
+
+// decodeLegacy converts flat {id, event, name} company message published before envelopes to version 0 envelope
+func decodeLegacy(fields map[string]string) (*Envelope, error) {
+	eventType, ok := fields["event"]
+	if !ok {
+		return nil, errors.New("message has neither type nor event")
+	}
+	aggregateID, err := uuid.Parse(fields["id"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid legacy id: %v", err)
+	}
+	payload, err := json.Marshal(map[string]string{"name": fields["name"]})
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		Type:        eventType,
+		AggregateID: aggregateID,
+		Payload:     payload,
+	}, nil
+}
+
+// deliver passes message to handler, message which cannot be decoded or handled is dead-lettered.
+// Redriven message is skipped by groups other than the one which dead-lettered it.
+// Handler attempts are spaced with backoff so short outages of handler dependencies don't dead-letter messages.
+// Error is returned when message was neither handled nor dead-lettered, so it must not be acknowledged
+func deliver(ctx context.Context, topic string, opts *SubscribeOptions, position string, fields map[string]string,
+	handler Handler) error {
+	if group, ok := fields[RedriveGroupField]; ok && group != opts.Group {
+		return nil
+	}
+	attempts := 1
+	envelope, err := Decode(fields)
+	if err == nil {
+		message := &Message{Envelope: *envelope, Position: position}
+		err = call(ctx, handler, message)
+		var retry backoff
+		for ; err != nil && attempts < maxAttempts && !errors.As(err, &permanentError{}); attempts++ {
+			if !sleep(ctx, retry.next()) {
+				return fmt.Errorf("%s message %s isn't handled before shutdown: %w", topic, position, err)
+			}
+			err = call(ctx, handler, message)
+		}
+	}
+	if err == nil {
+		opts.Status.consumed(position)
+		return nil
+	}
+
+	log.Errorf("skipping %s message %s: %v", topic, position, err)
+	opts.Status.skipped(position, err)
+	if opts.DeadLetter == nil {
+		return nil
+	}
+	dlqErr := opts.DeadLetter.Add(ctx, &dlq.Message{
+		Stream:    topic,
+		Group:     opts.Group,
+		MessageID: position,
+		Payload:   fields,
+		Error:     err.Error(),
+		Attempts:  attempts,
+		FailedAt:  time.Now(),
+	})
+	if dlqErr != nil {
+		return fmt.Errorf("cannot dead-letter %s message %s: %w", topic, position, dlqErr)
+	}
+	return nil
+}
+
+// call runs handler and turns its panic into error
+func call(ctx context.Context, handler Handler, message *Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+	}()
+	return handler(ctx, message)
+}
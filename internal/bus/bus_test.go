@@ -0,0 +1,150 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/dlq"
+)
+
+func TestDeliver_RetryBackoff(t *testing.T) {
+	t.Log("Given the need to test failed handler is retried after backoff delays.")
+	opts := &SubscribeOptions{Status: new(Status), DeadLetter: dlq.NewMemoryDeadLetter()}
+	var calls []time.Time
+	handler := func(ctx context.Context, message *Message) error {
+		calls = append(calls, time.Now())
+		if len(calls) < maxAttempts {
+			return errors.New("database is down")
+		}
+		return nil
+	}
+
+	err := deliver(context.Background(), "company", opts, "1", Encode(newEnvelope(t)), handler)
+	require.NoError(t, err)
+	require.Len(t, calls, maxAttempts)
+	require.GreaterOrEqual(t, calls[1].Sub(calls[0]), minBackoff/2)
+	require.GreaterOrEqual(t, calls[2].Sub(calls[1]), minBackoff)
+	require.Equal(t, "1", opts.Status.Health().LastMessageID)
+	messages, err := opts.DeadLetter.List(context.Background(), "", 10)
+	require.NoError(t, err)
+	require.Empty(t, messages)
+}
+
+func TestDeliver_Permanent(t *testing.T) {
+	t.Log("Given the need to test permanent handler error is dead-lettered without retries.")
+	opts := &SubscribeOptions{Status: new(Status), DeadLetter: dlq.NewMemoryDeadLetter()}
+	calls := 0
+	handler := func(ctx context.Context, message *Message) error {
+		calls++
+		return Permanent(errors.New("invalid payload"))
+	}
+
+	require.NoError(t, deliver(context.Background(), "company", opts, "1", Encode(newEnvelope(t)), handler))
+	require.Equal(t, 1, calls)
+	messages, err := opts.DeadLetter.List(context.Background(), "", 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Equal(t, 1, messages[0].Attempts)
+	require.Equal(t, int64(1), opts.Status.Health().Skipped)
+}
+
+func TestDeliver_PermanentPerGroup(t *testing.T) {
+	t.Log("Given the need to test message is dead-lettered once per consumer group which failed it.")
+	deadLetter := dlq.NewMemoryDeadLetter()
+	handler := func(ctx context.Context, message *Message) error {
+		return Permanent(errors.New("invalid payload"))
+	}
+	fields := Encode(newEnvelope(t))
+
+	for _, group := range []string{"cache", "cache", "billing"} {
+		opts := &SubscribeOptions{Group: group, Status: new(Status), DeadLetter: deadLetter}
+		require.NoError(t, deliver(context.Background(), "company", opts, "1", fields, handler))
+	}
+	messages, err := deadLetter.List(context.Background(), "", 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	require.Equal(t, "cache", messages[0].Group)
+	require.Equal(t, "billing", messages[1].Group)
+}
+
+func TestDeliver_RedriveGroup(t *testing.T) {
+	t.Log("Given the need to test redriven message is handled only by the group which dead-lettered it.")
+	fields := Encode(newEnvelope(t))
+	fields[RedriveGroupField] = "billing"
+	var handled []string
+	for _, group := range []string{"cache", "billing", ""} {
+		opts := &SubscribeOptions{Group: group, Status: new(Status), DeadLetter: dlq.NewMemoryDeadLetter()}
+		handler := func(ctx context.Context, message *Message) error {
+			handled = append(handled, group)
+			return nil
+		}
+		require.NoError(t, deliver(context.Background(), "company", opts, "2", fields, handler))
+	}
+	require.Equal(t, []string{"billing"}, handled)
+}
+
+func TestDeliver_Shutdown(t *testing.T) {
+	t.Log("Given the need to test retries stop on shutdown and message isn't dead-lettered.")
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := &SubscribeOptions{Status: new(Status), DeadLetter: dlq.NewMemoryDeadLetter()}
+	handler := func(ctx context.Context, message *Message) error {
+		cancel()
+		return errors.New("database is down")
+	}
+
+	start := time.Now()
+	err := deliver(ctx, "company", opts, "1", Encode(newEnvelope(t)), handler)
+	require.ErrorContains(t, err, "isn't handled before shutdown")
+	require.Less(t, time.Since(start), minBackoff/2)
+	messages, err := opts.DeadLetter.List(context.Background(), "", 10)
+	require.NoError(t, err)
+	require.Empty(t, messages)
+}
+
+func TestBackoff(t *testing.T) {
+	t.Log("Given the need to test backoff grows exponentially with jitter up to the limit.")
+	var retry backoff
+	for attempt := 0; attempt < 20; attempt++ {
+		limit := minBackoff << attempt
+		if limit > maxBackoff {
+			limit = maxBackoff
+		}
+		delay := retry.next()
+		require.GreaterOrEqual(t, delay, limit/2)
+		require.Less(t, delay, limit)
+	}
+	retry.reset()
+	require.Less(t, retry.next(), minBackoff)
+}
+
+func TestStatus_Health(t *testing.T) {
+	t.Log("Given the need to test health reflects running state, read failures and skipped messages.")
+	ctx, cancel := context.WithCancel(context.Background())
+	status := new(Status)
+	m := NewMemory(0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Subscribe(ctx, "company", SubscribeOptions{Status: status}, func(context.Context, *Message) error {
+			return nil
+		})
+	}()
+	require.Eventually(t, func() bool { return status.Health().Healthy() }, time.Second, 10*time.Millisecond)
+
+	status.readFailed(errors.New("connection refused"))
+	health := status.Health()
+	require.False(t, health.Healthy())
+	require.Equal(t, 1, health.Failures)
+	require.Equal(t, "connection refused", health.LastError)
+	status.readSucceeded()
+	require.True(t, status.Health().Healthy())
+
+	cancel()
+	<-done
+	require.False(t, status.Health().Running)
+	require.False(t, status.Health().Healthy())
+}
@@ -0,0 +1,110 @@
+package bus
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 30 * time.Second
+)
+
+// Health snapshot of subscription state
+type Health struct {
+	Running       bool      `json:"running"`
+	LastMessageID string    `json:"lastMessageID,omitempty"`
+	LastMessageAt time.Time `json:"lastMessageAt,omitempty"`
+	LastError     string    `json:"lastError,omitempty"`
+	LastErrorAt   time.Time `json:"lastErrorAt,omitempty"`
+	Failures      int       `json:"failures"`
+	Skipped       int64     `json:"skipped"`
+}
+
+// Healthy reports if subscription is running and its last read succeeded
+func (h Health) Healthy() bool {
+	return h.Running && h.Failures == 0
+}
+
+// Status tracks subscription health
+type Status struct {
+	mu     sync.RWMutex
+	health Health
+}
+
+// Health return current subscription health
+func (s *Status) Health() Health {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.health
+}
+
+func (s *Status) setRunning(running bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.Running = running
+}
+
+func (s *Status) readFailed(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.Failures++
+	s.health.LastError = err.Error()
+	s.health.LastErrorAt = time.Now()
+}
+
+func (s *Status) readSucceeded() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.Failures = 0
+}
+
+func (s *Status) consumed(messageID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.LastMessageID = messageID
+	s.health.LastMessageAt = time.Now()
+}
+
+func (s *Status) skipped(messageID string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.Skipped++
+	s.health.LastMessageID = messageID
+	s.health.LastError = err.Error()
+	s.health.LastErrorAt = time.Now()
+}
+
+// backoff exponential delay with jitter between failed reads
+type backoff struct {
+	attempt int
+}
+
+func (b *backoff) next() time.Duration {
+	d := minBackoff << b.attempt
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	} else {
+		b.attempt++
+	}
+	// random delay from [d/2, d) so restarted subscribers don't hit transport at once
+	return d/2 + time.Duration(rand.Int63n(int64(d/2))) //nolint:gosec
+}
+
+func (b *backoff) reset() {
+	b.attempt = 0
+}
+
+// sleep waits for d, returns false if ctx was canceled earlier
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
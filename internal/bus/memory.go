@@ -0,0 +1,172 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultMemoryRetention = 10000
+
+// Memory in-process event bus for tests and single node deployments, positions are message sequence numbers
+type Memory struct {
+	mu        sync.Mutex
+	topics    map[string]*memoryTopic
+	retention int
+}
+
+type memoryTopic struct {
+	// first sequence number of messages[0], sequence numbers start from 1
+	first    int64
+	messages []map[string]string
+	groups   map[string]int64
+	// notify is closed and replaced when message is published
+	notify chan struct{}
+}
+
+// NewMemory creates in-process event bus keeping last retention messages of every topic
+func NewMemory(retention int) *Memory {
+	if retention <= 0 {
+		retention = defaultMemoryRetention
+	}
+	return &Memory{
+		topics:    make(map[string]*memoryTopic),
+		retention: retention,
+	}
+}
+
+// Publish appends envelope to topic and returns its position
+func (m *Memory) Publish(ctx context.Context, topic string, envelope *Envelope) (string, error) {
+	return m.PublishRaw(ctx, topic, Encode(envelope))
+}
+
+// PublishRaw appends fields to topic as is
+func (m *Memory) PublishRaw(_ context.Context, topic string, fields map[string]string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.topic(topic)
+	t.messages = append(t.messages, fields)
+	if len(t.messages) > m.retention {
+		dropped := len(t.messages) - m.retention
+		t.messages = append([]map[string]string(nil), t.messages[dropped:]...)
+		t.first += int64(dropped)
+	}
+	close(t.notify)
+	t.notify = make(chan struct{})
+	return strconv.FormatInt(t.last(), 10), nil
+}
+
+// Subscribe delivers topic messages to handler until ctx is canceled
+func (m *Memory) Subscribe(ctx context.Context, topic string, opts SubscribeOptions, handler Handler) {
+	if opts.Status == nil {
+		opts.Status = new(Status)
+	}
+	opts.Status.setRunning(true)
+	defer opts.Status.setRunning(false)
+
+	m.mu.Lock()
+	cursor := m.topic(topic).last()
+	if opts.Group == "" && opts.From != "" {
+		from, err := strconv.ParseInt(opts.From, 10, 64)
+		if err != nil {
+			log.Errorf("invalid %s start position %s: %v", topic, opts.From, err)
+		} else {
+			cursor = from
+		}
+	}
+	if opts.Group != "" {
+		if _, ok := m.topic(topic).groups[opts.Group]; !ok {
+			m.topic(topic).groups[opts.Group] = cursor
+		}
+	}
+	m.mu.Unlock()
+
+	for ctx.Err() == nil {
+		position, fields, notify := m.next(topic, opts.Group, &cursor)
+		if fields == nil {
+			select {
+			case <-ctx.Done():
+			case <-notify:
+			}
+			continue
+		}
+		// memory topic has no pending list, message which wasn't handled or dead-lettered is lost
+		err := deliver(ctx, topic, &opts, strconv.FormatInt(position, 10), fields, handler)
+		if err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+// Range returns up to count retained messages after position
+func (m *Memory) Range(_ context.Context, topic, after string, count int64) ([]*Message, error) {
+	from, err := strconv.ParseInt(after, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s position %s: %v", topic, after, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.topic(topic)
+	if from < t.first-1 {
+		from = t.first - 1
+	}
+	var messages []*Message
+	for position := from + 1; position <= t.last() && int64(len(messages)) < count; position++ {
+		if _, ok := t.messages[position-t.first][RedriveGroupField]; ok {
+			continue
+		}
+		envelope, err := Decode(t.messages[position-t.first])
+		if err != nil {
+			log.Errorf("skipped undecodable %s message %d: %v", topic, position, err)
+			continue
+		}
+		messages = append(messages, &Message{Envelope: *envelope, Position: strconv.FormatInt(position, 10)})
+	}
+	return messages, nil
+}
+
+// next takes message after cursor, or after group cursor if group is set
+func (m *Memory) next(topic, group string, cursor *int64) (position int64, fields map[string]string,
+	notify chan struct{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.topic(topic)
+	if group != "" {
+		*cursor = t.groups[group]
+	}
+	if *cursor < t.first-1 {
+		log.Errorf("%s subscriber fell behind retention, skipped %d messages", topic, t.first-1-*cursor)
+		*cursor = t.first - 1
+	}
+	if *cursor >= t.last() {
+		return 0, nil, t.notify
+	}
+	*cursor++
+	if group != "" {
+		t.groups[group] = *cursor
+	}
+	return *cursor, t.messages[*cursor-t.first], nil
+}
+
+func (m *Memory) topic(name string) *memoryTopic {
+	t, ok := m.topics[name]
+	if !ok {
+		t = &memoryTopic{
+			first:  1,
+			groups: make(map[string]int64),
+			notify: make(chan struct{}),
+		}
+		m.topics[name] = t
+	}
+	return t
+}
+
+func (t *memoryTopic) last() int64 {
+	return t.first + int64(len(t.messages)) - 1
+}
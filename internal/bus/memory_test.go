@@ -0,0 +1,153 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/dlq"
+)
+
+func newEnvelope(t *testing.T) *Envelope {
+	payload, err := json.Marshal(map[string]string{"name": "Google"})
+	require.NoError(t, err)
+	return &Envelope{
+		ID:          uuid.New(),
+		Type:        "CREATE",
+		AggregateID: uuid.New(),
+		Version:     1,
+		Timestamp:   time.Now().UTC(),
+		Payload:     payload,
+	}
+}
+
+func TestMemory_FanOut(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	t.Log("Given the need to test every subscriber gets every message.")
+	m := NewMemory(0)
+	received := make(chan *Message, 2)
+	for i := 0; i < 2; i++ {
+		go m.Subscribe(ctx, "company", SubscribeOptions{From: "0"}, func(ctx context.Context, message *Message) error {
+			received <- message
+			return nil
+		})
+	}
+	envelope := newEnvelope(t)
+	position, err := m.Publish(ctx, "company", envelope)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		select {
+		case message := <-received:
+			require.Equal(t, position, message.Position)
+			require.Equal(t, envelope.ID, message.ID)
+			require.Equal(t, envelope.AggregateID, message.AggregateID)
+			require.JSONEq(t, string(envelope.Payload), string(message.Payload))
+		case <-time.After(time.Second):
+			t.Fatal("message wasn't delivered")
+		}
+	}
+}
+
+func TestMemory_Group(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	t.Log("Given the need to test group subscribers share messages.")
+	m := NewMemory(0)
+	var mu sync.Mutex
+	received := make(map[string]int)
+	var wg sync.WaitGroup
+	wg.Add(10)
+	for i := 0; i < 3; i++ {
+		go m.Subscribe(ctx, "company", SubscribeOptions{Group: "workers"}, func(ctx context.Context, message *Message) error {
+			mu.Lock()
+			received[message.Position]++
+			mu.Unlock()
+			wg.Done()
+			return nil
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		_, err := m.Publish(ctx, "company", newEnvelope(t))
+		require.NoError(t, err)
+	}
+	wg.Wait()
+	require.Len(t, received, 10)
+	for _, count := range received {
+		require.Equal(t, 1, count)
+	}
+}
+
+func TestMemory_DeadLetter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	t.Log("Given the need to test failed and undecodable messages are dead-lettered.")
+	m := NewMemory(0)
+	deadLetter := dlq.NewMemoryDeadLetter()
+	status := new(Status)
+	go m.Subscribe(ctx, "company", SubscribeOptions{From: "0", DeadLetter: deadLetter, Status: status},
+		func(ctx context.Context, message *Message) error {
+			panic("boom")
+		})
+	_, err := m.Publish(ctx, "company", newEnvelope(t))
+	require.NoError(t, err)
+	_, err = m.PublishRaw(ctx, "company", map[string]string{"type": "CREATE", "id": "not uuid"})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return status.Health().Skipped == 2
+	}, time.Second, 10*time.Millisecond)
+	messages, err := deadLetter.List(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	require.Equal(t, maxAttempts, messages[0].Attempts)
+	require.Contains(t, messages[0].Error, "boom")
+	require.Equal(t, 1, messages[1].Attempts)
+	require.Equal(t, "not uuid", messages[1].Payload["id"])
+}
+
+func TestDecode_Legacy(t *testing.T) {
+	t.Log("Given the need to test messages published before envelopes are decoded.")
+	id := uuid.New()
+	envelope, err := Decode(map[string]string{"id": id.String(), "event": "UPDATE", "name": "Google"})
+	require.NoError(t, err)
+	require.Equal(t, "UPDATE", envelope.Type)
+	require.Equal(t, id, envelope.AggregateID)
+	require.Equal(t, 0, envelope.Version)
+	require.JSONEq(t, `{"name":"Google"}`, string(envelope.Payload))
+
+	_, err = Decode(map[string]string{"name": "Google"})
+	require.Error(t, err)
+}
+
+// failingDeadLetter dead-letter storage which is unavailable
+type failingDeadLetter struct {
+	dlq.DeadLetter
+}
+
+func (failingDeadLetter) Add(context.Context, *dlq.Message) error {
+	return errors.New("dead-letter storage is down")
+}
+
+func TestDeliver_DeadLetterFailed(t *testing.T) {
+	t.Log("Given the need to test message which couldn't be dead-lettered is reported so it isn't acknowledged.")
+	fields := map[string]string{"type": "CREATE", "id": "broken"}
+	opts := &SubscribeOptions{Status: new(Status), DeadLetter: failingDeadLetter{}}
+	handler := func(ctx context.Context, message *Message) error { return nil }
+
+	err := deliver(context.Background(), "company", opts, "1", fields, handler)
+	require.ErrorContains(t, err, "cannot dead-letter company message 1")
+
+	opts.DeadLetter = dlq.NewMemoryDeadLetter()
+	require.NoError(t, deliver(context.Background(), "company", opts, "1", fields, handler))
+	messages, err := opts.DeadLetter.List(context.Background(), "", 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+}
@@ -0,0 +1,39 @@
+package bus
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Position parsed message position, redis stream ids are "<ms>-<seq>", memory positions are plain sequence numbers
+type Position struct {
+	Major uint64
+	Minor uint64
+}
+
+// ParsePosition parses position returned by any bus implementation
+func ParsePosition(s string) (Position, error) {
+	major, minor, found := strings.Cut(s, "-")
+	var p Position
+	var err error
+	p.Major, err = strconv.ParseUint(major, 10, 64)
+	if err != nil {
+		return Position{}, fmt.Errorf("invalid position %q", s)
+	}
+	if found {
+		p.Minor, err = strconv.ParseUint(minor, 10, 64)
+		if err != nil {
+			return Position{}, fmt.Errorf("invalid position %q", s)
+		}
+	}
+	return p, nil
+}
+
+// After reports whether p comes after other in a topic
+func (p Position) After(other Position) bool {
+	if p.Major != other.Major {
+		return p.Major > other.Major
+	}
+	return p.Minor > other.Minor
+}
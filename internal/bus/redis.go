@@ -0,0 +1,269 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	readCount    = 100
+	blockTimeout = 5 * time.Second
+)
+
+// Retention trimming policy of topic streams, zero values disable trimming
+type Retention struct {
+	// MaxLen approximate count of messages kept in a stream, takes precedence over MaxAge
+	MaxLen int64
+	// MaxAge approximate age of the oldest message kept in a stream
+	MaxAge time.Duration
+}
+
+// Redis event bus on top of redis streams, topic is a stream
+type Redis struct {
+	redis     *redis.Client
+	retention Retention
+}
+
+// NewRedis creates new redis streams event bus, streams are trimmed on publish according to retention
+func NewRedis(redisClient *redis.Client, retention Retention) *Redis {
+	return &Redis{redis: redisClient, retention: retention}
+}
+
+// Publish adds envelope to topic stream and returns its stream id
+func (r *Redis) Publish(ctx context.Context, topic string, envelope *Envelope) (string, error) {
+	return r.PublishRaw(ctx, topic, Encode(envelope))
+}
+
+// PublishRaw adds fields to topic stream as is
+func (r *Redis) PublishRaw(ctx context.Context, topic string, fields map[string]string) (string, error) {
+	values := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		values[k] = v
+	}
+	args := &redis.XAddArgs{
+		Stream: topic,
+		Values: values,
+		// "~" lets redis trim whole macro nodes only, which is much cheaper than exact trimming
+		Approx: true,
+		MaxLen: r.retention.MaxLen,
+	}
+	if args.MaxLen == 0 && r.retention.MaxAge > 0 {
+		args.MinID = fmt.Sprintf("%d-0", time.Now().Add(-r.retention.MaxAge).UnixMilli())
+	}
+	return r.redis.XAdd(ctx, args).Result()
+}
+
+// Subscribe reads topic stream with XREAD or, if group is set, with XREADGROUP until ctx is canceled
+func (r *Redis) Subscribe(ctx context.Context, topic string, opts SubscribeOptions, handler Handler) {
+	if opts.Status == nil {
+		opts.Status = new(Status)
+	}
+	opts.Status.setRunning(true)
+	defer opts.Status.setRunning(false)
+
+	if opts.Group == "" {
+		r.fanOut(ctx, topic, &opts, handler)
+		return
+	}
+	r.group(ctx, topic, &opts, handler)
+}
+
+// Pending return summary of messages delivered to the group but not acknowledged yet
+func (r *Redis) Pending(ctx context.Context, topic, group string) (*redis.XPending, error) {
+	return r.redis.XPending(ctx, topic, group).Result()
+}
+
+// Range returns up to count messages of topic stream with ids greater than after
+func (r *Redis) Range(ctx context.Context, topic, after string, count int64) ([]*Message, error) {
+	streamMessages, err := r.redis.XRangeN(ctx, topic, "("+after, "+", count).Result()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s stream range: %v", topic, err)
+	}
+	r.warnTrimmed(ctx, topic, after)
+	messages := make([]*Message, 0, len(streamMessages))
+	for _, message := range streamMessages {
+		if _, ok := message.Values[RedriveGroupField]; ok {
+			continue
+		}
+		envelope, err := Decode(fields(message))
+		if err != nil {
+			log.Errorf("skipped undecodable %s message %s: %v", topic, message.ID, err)
+			continue
+		}
+		messages = append(messages, &Message{Envelope: *envelope, Position: message.ID})
+	}
+	return messages, nil
+}
+
+// warnTrimmed logs when range starts before the oldest message kept in stream, messages in between were trimmed
+func (r *Redis) warnTrimmed(ctx context.Context, topic, after string) {
+	start, err := ParsePosition(after)
+	if err != nil || start == (Position{}) {
+		return
+	}
+	oldest, err := r.redis.XRangeN(ctx, topic, "-", "+", 1).Result()
+	if err != nil || len(oldest) == 0 {
+		return
+	}
+	oldestPosition, err := ParsePosition(oldest[0].ID)
+	if err == nil && oldestPosition.After(start) {
+		log.Warnf("%s stream range after %s starts before the oldest kept message %s, trimmed messages are skipped",
+			topic, after, oldest[0].ID)
+	}
+}
+
+func (r *Redis) fanOut(ctx context.Context, topic string, opts *SubscribeOptions, handler Handler) {
+	lastID := opts.From
+	if lastID == "" {
+		lastID = fmt.Sprintf("%d-0", time.Now().UnixMilli())
+	}
+
+	var retry backoff
+	for ctx.Err() == nil {
+		streams, err := r.redis.XRead(ctx, &redis.XReadArgs{
+			Streams: []string{topic, lastID},
+			Count:   readCount,
+			Block:   blockTimeout,
+		}).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Errorf("cannot read %s stream: %v", topic, err)
+			opts.Status.readFailed(err)
+			if !sleep(ctx, retry.next()) {
+				return
+			}
+			continue
+		}
+		retry.reset()
+		opts.Status.readSucceeded()
+
+		for _, stream := range streams {
+			for _, message := range stream.Messages {
+				lastID = message.ID
+				// fan-out subscription has no pending list to return message to
+				err = deliver(ctx, topic, opts, message.ID, fields(message), handler)
+				if err != nil {
+					log.Error(err)
+				}
+			}
+		}
+	}
+}
+
+func (r *Redis) group(ctx context.Context, topic string, opts *SubscribeOptions, handler Handler) {
+	var retry backoff
+	for !r.createGroup(ctx, topic, opts) {
+		if !sleep(ctx, retry.next()) {
+			return
+		}
+	}
+	retry.reset()
+
+	// messages delivered to this consumer before restart come first, pending list is read once,
+	// messages left pending after the pass are delivered again by claimStale once they are idle for MinIdle
+	lastID := "0"
+	for ctx.Err() == nil {
+		err := r.claimStale(ctx, topic, opts, handler)
+		if err == nil {
+			var streams []redis.XStream
+			streams, err = r.redis.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    opts.Group,
+				Consumer: opts.Consumer,
+				Streams:  []string{topic, lastID},
+				Count:    readCount,
+				Block:    blockTimeout,
+			}).Result()
+			if err == nil {
+				messages := streams[0].Messages
+				if lastID != ">" {
+					if len(messages) == 0 {
+						lastID = ">"
+					} else {
+						lastID = messages[len(messages)-1].ID
+					}
+				}
+				r.process(ctx, topic, opts, messages, handler)
+			}
+		}
+		if err != nil && !errors.Is(err, redis.Nil) {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Errorf("cannot read %s stream as %s/%s: %v", topic, opts.Group, opts.Consumer, err)
+			opts.Status.readFailed(err)
+			if !sleep(ctx, retry.next()) {
+				return
+			}
+			continue
+		}
+		retry.reset()
+		opts.Status.readSucceeded()
+	}
+}
+
+func (r *Redis) createGroup(ctx context.Context, topic string, opts *SubscribeOptions) bool {
+	err := r.redis.XGroupCreateMkStream(ctx, topic, opts.Group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		err = fmt.Errorf("cannot create consumer group %s: %v", opts.Group, err)
+		log.Error(err)
+		opts.Status.readFailed(err)
+		return false
+	}
+	return true
+}
+
+// claimStale takes over messages which other consumers of the group failed to acknowledge in time
+func (r *Redis) claimStale(ctx context.Context, topic string, opts *SubscribeOptions, handler Handler) error {
+	start := "0-0"
+	for {
+		messages, next, err := r.redis.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   topic,
+			Group:    opts.Group,
+			Consumer: opts.Consumer,
+			MinIdle:  opts.MinIdle,
+			Start:    start,
+			Count:    readCount,
+		}).Result()
+		if err != nil {
+			return err
+		}
+		r.process(ctx, topic, opts, messages, handler)
+		if next == "0-0" || len(messages) == 0 {
+			return nil
+		}
+		start = next
+	}
+}
+
+func (r *Redis) process(ctx context.Context, topic string, opts *SubscribeOptions, messages []redis.XMessage,
+	handler Handler) {
+	for _, message := range messages {
+		err := deliver(ctx, topic, opts, message.ID, fields(message), handler)
+		if err != nil {
+			// message stays pending and is claimed again once it is idle for MinIdle
+			log.Error(err)
+			continue
+		}
+
+		err = r.redis.XAck(ctx, topic, opts.Group, message.ID).Err()
+		if err != nil {
+			log.Errorf("cannot ack %s message %s: %v", topic, message.ID, err)
+		}
+	}
+}
+
+func fields(message redis.XMessage) map[string]string {
+	result := make(map[string]string, len(message.Values))
+	for k, v := range message.Values {
+		result[k] = fmt.Sprint(v)
+	}
+	return result
+}
@@ -0,0 +1,321 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/google/uuid"
+	"github.com/ory/dockertest"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/dlq"
+)
+
+// redisClient is nil when docker isn't available, redis tests are skipped then
+var redisClient *redis.Client
+
+func TestMain(m *testing.M) {
+	resource, pool := startRedis()
+	code := m.Run()
+	if resource != nil {
+		if err := pool.Purge(resource); err != nil {
+			log.Printf("Could not purge resource: %s\n", err)
+		}
+	}
+	os.Exit(code)
+}
+
+func startRedis() (*dockertest.Resource, *dockertest.Pool) {
+	pool, err := dockertest.NewPool("unix:///home/entetry/.docker/desktop/docker.sock")
+	if err != nil {
+		log.Printf("Could not connect to docker: %s", err)
+		return nil, nil
+	}
+	resource, err := pool.Run("redis", "7-alpine", nil)
+	if err != nil {
+		log.Printf("Could not start resource: %s", err)
+		return nil, nil
+	}
+	err = pool.Retry(func() error {
+		redisClient = redis.NewClient(&redis.Options{
+			Addr: fmt.Sprintf("localhost:%s", resource.GetPort("6379/tcp")),
+		})
+		return redisClient.Ping(context.Background()).Err()
+	})
+	if err != nil {
+		log.Printf("Could not connect to redis: %s", err)
+		redisClient = nil
+	}
+	return resource, pool
+}
+
+func newRedisBus(t *testing.T, retention Retention) *Redis {
+	if redisClient == nil {
+		t.Skip("redis container is not available")
+	}
+	return NewRedis(redisClient, retention)
+}
+
+// subscribeGroup starts group consumer and waits until its group exists, returned func stops it
+func subscribeGroup(t *testing.T, r *Redis, topic string, opts SubscribeOptions, handler Handler) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Subscribe(ctx, topic, opts, handler)
+	}()
+	require.Eventually(t, func() bool {
+		groups, err := redisClient.XInfoGroups(context.Background(), topic).Result()
+		return err == nil && len(groups) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func requirePending(t *testing.T, r *Redis, topic, group string, count int64) {
+	require.Eventually(t, func() bool {
+		pending, err := r.Pending(context.Background(), topic, group)
+		return err == nil && pending.Count == count
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRedis_GroupAck(t *testing.T) {
+	t.Log("Given the need to test group consumer acknowledges handled messages.")
+	r := newRedisBus(t, Retention{})
+	topic := "company." + uuid.NewString()
+	received := make(chan *Message, 1)
+	stop := subscribeGroup(t, r, topic, SubscribeOptions{Group: "cache", Consumer: "a", MinIdle: time.Minute},
+		func(ctx context.Context, message *Message) error {
+			received <- message
+			return nil
+		})
+	defer stop()
+
+	envelope := newEnvelope(t)
+	position, err := r.Publish(context.Background(), topic, envelope)
+	require.NoError(t, err)
+	select {
+	case message := <-received:
+		require.Equal(t, position, message.Position)
+		require.Equal(t, envelope.ID, message.ID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message wasn't delivered")
+	}
+	requirePending(t, r, topic, "cache", 0)
+}
+
+func TestRedis_GroupRestart(t *testing.T) {
+	t.Log("Given the need to test message unhandled before shutdown is delivered again after restart.")
+	r := newRedisBus(t, Retention{})
+	topic := "company." + uuid.NewString()
+	opts := SubscribeOptions{Group: "cache", Consumer: "a", MinIdle: time.Minute}
+	started := make(chan struct{}, 1)
+	stop := subscribeGroup(t, r, topic, opts, func(ctx context.Context, message *Message) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	position, err := r.Publish(context.Background(), topic, newEnvelope(t))
+	require.NoError(t, err)
+	<-started
+	stop()
+	requirePending(t, r, topic, "cache", 1)
+
+	received := make(chan string, 1)
+	stop = subscribeGroup(t, r, topic, opts, func(ctx context.Context, message *Message) error {
+		received <- message.Position
+		return nil
+	})
+	defer stop()
+	select {
+	case redelivered := <-received:
+		require.Equal(t, position, redelivered)
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending message wasn't redelivered")
+	}
+	requirePending(t, r, topic, "cache", 0)
+}
+
+func TestRedis_GroupPendingDoesNotBlock(t *testing.T) {
+	t.Log("Given the need to test message left pending after restart doesn't block new messages.")
+	r := newRedisBus(t, Retention{})
+	topic := "company." + uuid.NewString()
+	opts := SubscribeOptions{Group: "cache", Consumer: "a", MinIdle: time.Minute, DeadLetter: failingDeadLetter{}}
+	stuck, err := r.Publish(context.Background(), topic, newEnvelope(t))
+	require.NoError(t, err)
+	handler := func(ctx context.Context, message *Message) error {
+		return Permanent(errors.New("invalid payload"))
+	}
+	stop := subscribeGroup(t, r, topic, opts, handler)
+	requirePending(t, r, topic, "cache", 1)
+	stop()
+
+	received := make(chan string, 10)
+	stop = subscribeGroup(t, r, topic, opts, func(ctx context.Context, message *Message) error {
+		received <- message.Position
+		if message.Position == stuck {
+			return Permanent(errors.New("invalid payload"))
+		}
+		return nil
+	})
+	defer stop()
+	position, err := r.Publish(context.Background(), topic, newEnvelope(t))
+	require.NoError(t, err)
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case delivered := <-received:
+			if delivered == position {
+				requirePending(t, r, topic, "cache", 1)
+				return
+			}
+		case <-timeout:
+			t.Fatal("new message wasn't delivered while another one stays pending")
+		}
+	}
+}
+
+func TestRedis_ClaimStale(t *testing.T) {
+	t.Log("Given the need to test messages left pending by dead consumer are claimed by live one.")
+	r := newRedisBus(t, Retention{})
+	ctx := context.Background()
+	topic := "company." + uuid.NewString()
+	require.NoError(t, redisClient.XGroupCreateMkStream(ctx, topic, "cache", "$").Err())
+	position, err := r.Publish(ctx, topic, newEnvelope(t))
+	require.NoError(t, err)
+	_, err = redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group: "cache", Consumer: "dead", Streams: []string{topic, ">"}, Count: 1,
+	}).Result()
+	require.NoError(t, err)
+	requirePending(t, r, topic, "cache", 1)
+
+	received := make(chan string, 1)
+	opts := SubscribeOptions{Group: "cache", Consumer: "alive", MinIdle: 50 * time.Millisecond}
+	stop := subscribeGroup(t, r, topic, opts, func(ctx context.Context, message *Message) error {
+		received <- message.Position
+		return nil
+	})
+	defer stop()
+	select {
+	case claimed := <-received:
+		require.Equal(t, position, claimed)
+	case <-time.After(10 * time.Second):
+		t.Fatal("stale message wasn't claimed")
+	}
+	requirePending(t, r, topic, "cache", 0)
+}
+
+func TestRedis_DeadLetterFailedStaysPending(t *testing.T) {
+	t.Log("Given the need to test message which couldn't be dead-lettered isn't acknowledged.")
+	r := newRedisBus(t, Retention{})
+	topic := "company." + uuid.NewString()
+	handled := make(chan struct{}, maxAttempts)
+	stop := subscribeGroup(t, r, topic, SubscribeOptions{Group: "cache", Consumer: "a", MinIdle: time.Minute,
+		DeadLetter: failingDeadLetter{}}, func(ctx context.Context, message *Message) error {
+		handled <- struct{}{}
+		return Permanent(errors.New("invalid payload"))
+	})
+	defer stop()
+
+	_, err := r.Publish(context.Background(), topic, newEnvelope(t))
+	require.NoError(t, err)
+	<-handled
+	requirePending(t, r, topic, "cache", 1)
+}
+
+// addOld adds count messages with ids of 1970 so age based trimming removes them
+func addOld(t *testing.T, topic string, count int) {
+	for i := 1; i <= count; i++ {
+		values := make(map[string]interface{})
+		for k, v := range Encode(newEnvelope(t)) {
+			values[k] = v
+		}
+		err := redisClient.XAdd(context.Background(), &redis.XAddArgs{
+			Stream: topic, ID: fmt.Sprintf("%d-0", i), Values: values,
+		}).Err()
+		require.NoError(t, err)
+	}
+}
+
+func streamLength(t *testing.T, topic string) int64 {
+	length, err := redisClient.XLen(context.Background(), topic).Result()
+	require.NoError(t, err)
+	return length
+}
+
+func TestRedis_RetentionMaxLen(t *testing.T) {
+	t.Log("Given the need to test streams are trimmed to approximate length on publish.")
+	r := newRedisBus(t, Retention{MaxLen: 10})
+	topic := "company." + uuid.NewString()
+	for i := 0; i < 300; i++ {
+		_, err := r.Publish(context.Background(), topic, newEnvelope(t))
+		require.NoError(t, err)
+	}
+	length := streamLength(t, topic)
+	require.GreaterOrEqual(t, length, int64(10))
+	require.Less(t, length, int64(300))
+}
+
+func TestRedis_RetentionMaxAge(t *testing.T) {
+	t.Log("Given the need to test messages older than max age are trimmed on publish.")
+	r := newRedisBus(t, Retention{MaxAge: time.Hour})
+	topic := "company." + uuid.NewString()
+	addOld(t, topic, 300)
+	_, err := r.Publish(context.Background(), topic, newEnvelope(t))
+	require.NoError(t, err)
+	require.Less(t, streamLength(t, topic), int64(301))
+
+	messages, err := r.Range(context.Background(), topic, "1-0", 10)
+	require.NoError(t, err)
+	require.NotEmpty(t, messages)
+}
+
+func TestRedis_RetentionLengthWins(t *testing.T) {
+	t.Log("Given the need to test max length takes precedence over max age.")
+	r := newRedisBus(t, Retention{MaxLen: 1000, MaxAge: time.Hour})
+	topic := "company." + uuid.NewString()
+	addOld(t, topic, 300)
+	_, err := r.Publish(context.Background(), topic, newEnvelope(t))
+	require.NoError(t, err)
+	require.Equal(t, int64(301), streamLength(t, topic))
+}
+
+func TestRedis_DeadLetterGroupsAndTrim(t *testing.T) {
+	t.Log("Given the need to test redis dead-letter stores message once per group and is trimmed on add.")
+	if redisClient == nil {
+		t.Skip("redis container is not available")
+	}
+	deadLetter := dlq.NewRedisCompanyDeadLetter(redisClient, 10, 0)
+	topic := "company." + uuid.NewString()
+	for _, group := range []string{"cache", "cache", "billing"} {
+		err := deadLetter.Add(context.Background(), &dlq.Message{
+			Stream: topic, Group: group, MessageID: "1-0", Payload: Encode(newEnvelope(t)), FailedAt: time.Now(),
+		})
+		require.NoError(t, err)
+	}
+	messages, err := deadLetter.List(context.Background(), "", 300)
+	require.NoError(t, err)
+	var groups []string
+	for _, message := range messages {
+		if message.Stream == topic {
+			groups = append(groups, message.Group)
+		}
+	}
+	require.Equal(t, []string{"cache", "billing"}, groups)
+
+	for i := 0; i < 300; i++ {
+		err = deadLetter.Add(context.Background(), &dlq.Message{
+			Stream: topic, Group: "cache", MessageID: fmt.Sprintf("%d-1", i), Payload: Encode(newEnvelope(t)),
+		})
+		require.NoError(t, err)
+	}
+	require.Less(t, streamLength(t, dlq.CompanyStream), int64(300))
+}
@@ -4,6 +4,7 @@ package cache
 import (
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -14,24 +15,36 @@ type Cache interface {
 	Update(ID uuid.UUID, name string)
 	Read(id uuid.UUID) (*model.Company, error)
 	Delete(id uuid.UUID)
+	MarkMissing(id uuid.UUID)
+	IsMissing(id uuid.UUID) bool
 }
 
 // LocalCache cache company struct
 type LocalCache struct {
-	stop      chan struct{}
-	mu        sync.RWMutex
-	companies map[uuid.UUID]model.Company
+	stop       chan struct{}
+	mu         sync.RWMutex
+	companies  map[uuid.UUID]model.Company
+	missing    map[uuid.UUID]time.Time
+	missingTTL time.Duration
+	maxMissing int
 }
 
 var (
 	errUserNotInCache = errors.New("the company isn't in cache")
 )
 
-// NewLocalCache creates new company cache object
-func NewLocalCache() *LocalCache {
+// NewLocalCache creates new company cache object, not found ids are remembered for missingTTL
+// and at most maxMissing of them are kept at once
+func NewLocalCache(missingTTL time.Duration, maxMissing int) *LocalCache {
 	lc := &LocalCache{
-		companies: make(map[uuid.UUID]model.Company),
-		stop:      make(chan struct{}),
+		companies:  make(map[uuid.UUID]model.Company),
+		missing:    make(map[uuid.UUID]time.Time),
+		missingTTL: missingTTL,
+		maxMissing: maxMissing,
+		stop:       make(chan struct{}),
+	}
+	if missingTTL > 0 {
+		go lc.cleanupMissing()
 	}
 
 	return lc
@@ -42,6 +55,7 @@ func (lc *LocalCache) Update(ID uuid.UUID, name string) {
 	lc.mu.Lock()
 	defer lc.mu.Unlock()
 
+	delete(lc.missing, ID)
 	lc.companies[ID] = model.Company{ID: ID, Name: name}
 }
 
@@ -65,3 +79,50 @@ func (lc *LocalCache) Delete(id uuid.UUID) {
 
 	delete(lc.companies, id)
 }
+
+// MarkMissing remember that company doesn't exist( no-op when negative cache is full or disabled)
+func (lc *LocalCache) MarkMissing(id uuid.UUID) {
+	if lc.missingTTL <= 0 {
+		return
+	}
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if _, ok := lc.missing[id]; !ok && len(lc.missing) >= lc.maxMissing {
+		return
+	}
+	lc.missing[id] = time.Now().Add(lc.missingTTL)
+}
+
+// IsMissing checks if company was recently not found
+func (lc *LocalCache) IsMissing(id uuid.UUID) bool {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	expiresAt, ok := lc.missing[id]
+	return ok && time.Now().Before(expiresAt)
+}
+
+// Close stops background cleanup of the cache
+func (lc *LocalCache) Close() {
+	close(lc.stop)
+}
+
+func (lc *LocalCache) cleanupMissing() {
+	ticker := time.NewTicker(lc.missingTTL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lc.stop:
+			return
+		case now := <-ticker.C:
+			lc.mu.Lock()
+			for id, expiresAt := range lc.missing {
+				if now.After(expiresAt) {
+					delete(lc.missing, id)
+				}
+			}
+			lc.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalCache_MissingExpires(t *testing.T) {
+	t.Log("Given the need to test missing companies are forgotten after ttl and cleaned up.")
+	lc := NewLocalCache(50*time.Millisecond, 10)
+	defer lc.Close()
+	id := uuid.New()
+
+	require.False(t, lc.IsMissing(id))
+	lc.MarkMissing(id)
+	require.True(t, lc.IsMissing(id))
+
+	time.Sleep(60 * time.Millisecond)
+	require.False(t, lc.IsMissing(id))
+	require.Eventually(t, func() bool {
+		lc.mu.RLock()
+		defer lc.mu.RUnlock()
+		return len(lc.missing) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLocalCache_MissingBound(t *testing.T) {
+	t.Log("Given the need to test negative cache keeps at most maxMissing ids.")
+	lc := NewLocalCache(time.Minute, 2)
+	defer lc.Close()
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+
+	lc.MarkMissing(first)
+	lc.MarkMissing(second)
+	lc.MarkMissing(third)
+	require.True(t, lc.IsMissing(first))
+	require.True(t, lc.IsMissing(second))
+	require.False(t, lc.IsMissing(third))
+
+	lc.MarkMissing(first)
+	require.True(t, lc.IsMissing(first))
+}
+
+func TestLocalCache_MissingDisabled(t *testing.T) {
+	t.Log("Given the need to test zero ttl disables negative cache.")
+	lc := NewLocalCache(0, 10)
+	defer lc.Close()
+	id := uuid.New()
+
+	lc.MarkMissing(id)
+	require.False(t, lc.IsMissing(id))
+}
+
+func TestLocalCache_UpdateClearsMissing(t *testing.T) {
+	t.Log("Given the need to test created company is no longer reported missing.")
+	lc := NewLocalCache(time.Minute, 10)
+	defer lc.Close()
+	id := uuid.New()
+	lc.MarkMissing(id)
+
+	lc.Update(id, "Google")
+	require.False(t, lc.IsMissing(id))
+	company, err := lc.Read(id)
+	require.NoError(t, err)
+	require.Equal(t, "Google", company.Name)
+
+	lc.Delete(id)
+	_, err = lc.Read(id)
+	require.Error(t, err)
+}
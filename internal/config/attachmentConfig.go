@@ -0,0 +1,31 @@
+package config
+
+import (
+	"time"
+
+	"github.com/caarlos0/env/v6"
+)
+
+// AttachmentConfig config of company attachments
+type AttachmentConfig struct {
+	// MaxSize limit of attachment size in bytes
+	MaxSize int64 `env:"ATTACHMENT_MAX_SIZE" envDefault:"1073741824"`
+	// MaxChunk limit of uploaded chunk size in bytes, chunk is held in memory while its checksum is verified
+	MaxChunk int64 `env:"ATTACHMENT_MAX_CHUNK" envDefault:"16777216"`
+	// UploadTTL incomplete uploads older than it are deleted by garbage collection
+	UploadTTL time.Duration `env:"ATTACHMENT_UPLOAD_TTL" envDefault:"24h"`
+	// GCInterval interval of expired uploads and orphaned chunk blobs collection, 0 disables it
+	GCInterval time.Duration `env:"ATTACHMENT_GC_INTERVAL" envDefault:"1h"`
+	// GCGrace unreferenced chunk blobs younger than it are kept, they may belong to chunks being appended
+	GCGrace time.Duration `env:"ATTACHMENT_GC_GRACE" envDefault:"1h"`
+}
+
+// NewAttachmentConfig creates new AttachmentConfig object
+func NewAttachmentConfig() (*AttachmentConfig, error) {
+	cfg := new(AttachmentConfig)
+	err := env.Parse(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -2,9 +2,19 @@
 package config
 
 import (
+	"os"
+	"time"
+
 	"github.com/caarlos0/env/v6"
 )
 
+const (
+	// RedisBus event bus on top of redis streams
+	RedisBus = "redis"
+	// MemoryBus in-process event bus for single node deployments
+	MemoryBus = "memory"
+)
+
 // Config Main application config
 type Config struct {
 	Port             int    `env:"APP_PORT" envDefault:"22800"`
@@ -12,6 +22,42 @@ type Config struct {
 	RedisPort        int    `env:"REDIS_PORT" envDefault:"6379"`
 	RedisHost        string `env:"REDIS_HOST" envDefault:"localhost"`
 	RedisPass        string `env:"REDIS_PASS" envDefault:""`
+
+	// EventBus transport of company events, redis or memory
+	EventBus           string `env:"EVENT_BUS" envDefault:"redis"`
+	MemoryBusRetention int    `env:"MEMORY_BUS_RETENTION" envDefault:"10000"`
+
+	// StreamMaxLen and StreamMaxAge approximate trimming of redis streams, length wins when both are set.
+	// Trimming doesn't wait for consumer groups or SSE clients: messages not acknowledged yet and
+	// Last-Event-ID replays older than the oldest kept message are lost, keep retention above the longest outage
+	StreamMaxLen int64         `env:"STREAM_MAX_LEN" envDefault:"100000"`
+	StreamMaxAge time.Duration `env:"STREAM_MAX_AGE" envDefault:"0s"`
+	// DeadLetterMaxLen and DeadLetterMaxAge approximate trimming of company dead-letter stream, length wins
+	DeadLetterMaxLen int64         `env:"DEAD_LETTER_MAX_LEN" envDefault:"10000"`
+	DeadLetterMaxAge time.Duration `env:"DEAD_LETTER_MAX_AGE" envDefault:"0s"`
+
+	// CacheFillBroadcast publishes UPDATE event when company is read from db so other replicas cache it too
+	CacheFillBroadcast bool `env:"CACHE_FILL_BROADCAST" envDefault:"false"`
+
+	// RedisConsumerGroup and RedisConsumerName are used by workers which share company events
+	RedisConsumerGroup   string        `env:"REDIS_CONSUMER_GROUP" envDefault:"gocompany"`
+	RedisConsumerName    string        `env:"REDIS_CONSUMER_NAME"`
+	RedisConsumerMinIdle time.Duration `env:"REDIS_CONSUMER_MIN_IDLE" envDefault:"1m"`
+
+	// AdminUserIDs users allowed to use admin endpoints
+	AdminUserIDs []string `env:"ADMIN_USER_IDS" envSeparator:","`
+
+	// OutboxInterval how often pending company events are polled from outbox
+	OutboxInterval time.Duration `env:"OUTBOX_INTERVAL" envDefault:"500ms"`
+
+	NegativeCacheTTL  time.Duration `env:"NEGATIVE_CACHE_TTL" envDefault:"30s"`
+	NegativeCacheSize int           `env:"NEGATIVE_CACHE_SIZE" envDefault:"10000"`
+
+	// FeedBuffer events a company feed client may lag behind before it is disconnected
+	FeedBuffer    int           `env:"FEED_BUFFER" envDefault:"256"`
+	FeedHeartbeat time.Duration `env:"FEED_HEARTBEAT" envDefault:"15s"`
+	// SocketMaxCompanies companies single websocket connection may watch
+	SocketMaxCompanies int `env:"SOCKET_MAX_COMPANIES" envDefault:"500"`
 }
 
 // New Creates Config object
@@ -21,5 +67,11 @@ func New() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if cfg.RedisConsumerName == "" {
+		cfg.RedisConsumerName, err = os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+	}
 	return cfg, nil
 }
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"time"
+
+	"github.com/caarlos0/env/v6"
+)
+
+// LogoConfig config of company logos
+type LogoConfig struct {
+	MaxBytes  int64 `env:"LOGO_MAX_BYTES" envDefault:"2097152"`
+	MaxWidth  int   `env:"LOGO_MAX_WIDTH" envDefault:"4096"`
+	MaxHeight int   `env:"LOGO_MAX_HEIGHT" envDefault:"4096"`
+	// Renditions sizes of longer side of resized logos, generated on first request
+	Renditions []int `env:"LOGO_RENDITIONS" envSeparator:"," envDefault:"32,64,128,512"`
+	// GCInterval interval of orphaned logo blobs collection, 0 disables it
+	GCInterval time.Duration `env:"LOGO_GC_INTERVAL" envDefault:"24h"`
+	// GCGrace unreferenced blobs younger than it are kept, they may belong to uploads in progress
+	GCGrace time.Duration `env:"LOGO_GC_GRACE" envDefault:"1h"`
+	// URLKeys id:secret pairs signing public logo urls, the first one signs new urls and all of them are
+	// accepted, so a key is rotated by putting new one first and dropping old one once its urls expired. Required
+	URLKeys   []string      `env:"LOGO_URL_KEYS,notEmpty" envSeparator:","`
+	URLTTL    time.Duration `env:"LOGO_URL_TTL" envDefault:"24h"`
+	URLMaxTTL time.Duration `env:"LOGO_URL_MAX_TTL" envDefault:"720h"`
+	// PublicURL base url public logo urls are issued with, taken from request if empty
+	PublicURL string `env:"PUBLIC_URL"`
+}
+
+// NewLogoConfig creates new LogoConfig object
+func NewLogoConfig() (*LogoConfig, error) {
+	cfg := new(LogoConfig)
+	err := env.Parse(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
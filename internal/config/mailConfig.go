@@ -0,0 +1,52 @@
+package config
+
+import (
+	"time"
+
+	"github.com/caarlos0/env/v6"
+)
+
+const (
+	// SMTPMail emails are sent through SMTP server
+	SMTPMail = "smtp"
+	// LogMail emails are written to log
+	LogMail = "log"
+	// FileMail emails are written to files
+	FileMail = "file"
+)
+
+// MailConfig config of emails sent to users
+type MailConfig struct {
+	// Sender of emails, smtp, log or file
+	Sender  string `env:"MAIL_SENDER" envDefault:"log"`
+	From    string `env:"MAIL_FROM" envDefault:"noreply@gocompany.local"`
+	FileDir string `env:"MAIL_FILE_DIR" envDefault:"data/mail"`
+
+	SMTPHost     string `env:"SMTP_HOST" envDefault:"localhost"`
+	SMTPPort     int    `env:"SMTP_PORT" envDefault:"587"`
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
+
+	// TokenKey signs tokens sent by email, required
+	TokenKey string `env:"MAIL_TOKEN_KEY,notEmpty"`
+	// VerificationRequired users can't sign in before they verify email
+	VerificationRequired bool          `env:"EMAIL_VERIFICATION_REQUIRED" envDefault:"false"`
+	VerificationTTL      time.Duration `env:"EMAIL_VERIFICATION_TTL" envDefault:"24h"`
+	// VerificationURL link sent to user, token is appended to it
+	VerificationURL  string        `env:"EMAIL_VERIFICATION_URL" envDefault:"http://localhost:3000/verify-email?token="`
+	PasswordResetTTL time.Duration `env:"PASSWORD_RESET_TTL" envDefault:"1h"`
+	// PasswordResetURL link sent to user, token is appended to it
+	PasswordResetURL string `env:"PASSWORD_RESET_URL" envDefault:"http://localhost:3000/reset-password?token="`
+	// ResendInterval minimal interval between emails sent to the same user
+	ResendInterval time.Duration `env:"EMAIL_RESEND_INTERVAL" envDefault:"1m"`
+}
+
+// NewMailConfig creates new MailConfig object
+func NewMailConfig() (*MailConfig, error) {
+	cfg := new(MailConfig)
+	err := env.Parse(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"github.com/caarlos0/env/v6"
+)
+
+const (
+	// Argon2idHash passwords are hashed with argon2id
+	Argon2idHash = "argon2id"
+	// BcryptHash passwords are hashed with bcrypt
+	BcryptHash = "bcrypt"
+)
+
+// PasswordConfig config of password hashing, hashes made with other algorithm or parameters are
+// upgraded when user signs in
+type PasswordConfig struct {
+	// Hash algorithm of new hashes, argon2id or bcrypt
+	Hash       string `env:"PASSWORD_HASH" envDefault:"argon2id"`
+	BcryptCost int    `env:"PASSWORD_BCRYPT_COST" envDefault:"12"`
+	// Argon2Time number of passes over memory
+	Argon2Time uint32 `env:"PASSWORD_ARGON2_TIME" envDefault:"3"`
+	// Argon2Memory memory used by hashing in KiB
+	Argon2Memory  uint32 `env:"PASSWORD_ARGON2_MEMORY" envDefault:"65536"`
+	Argon2Threads uint8  `env:"PASSWORD_ARGON2_THREADS" envDefault:"2"`
+
+	MinLength int `env:"PASSWORD_MIN_LENGTH" envDefault:"8"`
+	MaxLength int `env:"PASSWORD_MAX_LENGTH" envDefault:"64"`
+	// MinClasses number of classes out of lowercase, uppercase, digits and other characters
+	MinClasses int `env:"PASSWORD_MIN_CLASSES" envDefault:"2"`
+	// RejectUserInfo rejects passwords containing username or email
+	RejectUserInfo bool `env:"PASSWORD_REJECT_USER_INFO" envDefault:"true"`
+	// BreachedDir directory of breached password range files, check is disabled when empty
+	BreachedDir string `env:"PASSWORD_BREACHED_DIR"`
+}
+
+// NewPasswordConfig creates new PasswordConfig object
+func NewPasswordConfig() (*PasswordConfig, error) {
+	cfg := new(PasswordConfig)
+	err := env.Parse(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
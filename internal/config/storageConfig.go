@@ -0,0 +1,37 @@
+package config
+
+import (
+	"github.com/caarlos0/env/v6"
+)
+
+const (
+	// LocalStore blobs are files on local disk
+	LocalStore = "local"
+	// PostgresStore blobs are kept in postgres blob table
+	PostgresStore = "postgres"
+	// S3Store blobs are objects of S3 compatible storage
+	S3Store = "s3"
+)
+
+// StorageConfig config of uploaded files storage
+type StorageConfig struct {
+	// BlobStore backend of uploaded files, local, postgres or s3
+	BlobStore string `env:"BLOB_STORE" envDefault:"local"`
+	LocalDir  string `env:"BLOB_LOCAL_DIR" envDefault:"data/company"`
+
+	S3Endpoint  string `env:"S3_ENDPOINT"`
+	S3Region    string `env:"S3_REGION" envDefault:"us-east-1"`
+	S3Bucket    string `env:"S3_BUCKET"`
+	S3AccessKey string `env:"S3_ACCESS_KEY"`
+	S3SecretKey string `env:"S3_SECRET_KEY"`
+}
+
+// NewStorageConfig creates new StorageConfig object
+func NewStorageConfig() (*StorageConfig, error) {
+	cfg := new(StorageConfig)
+	err := env.Parse(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
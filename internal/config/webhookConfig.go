@@ -0,0 +1,31 @@
+package config
+
+import (
+	"time"
+
+	"github.com/caarlos0/env/v6"
+)
+
+// WebhookConfig config of outgoing webhooks delivery
+type WebhookConfig struct {
+	Timeout      time.Duration `env:"WEBHOOK_TIMEOUT" envDefault:"10s"`
+	PollInterval time.Duration `env:"WEBHOOK_POLL_INTERVAL" envDefault:"1s"`
+	// Concurrency count of deliveries sent at once
+	Concurrency int `env:"WEBHOOK_CONCURRENCY" envDefault:"10"`
+	MaxAttempts int `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"8"`
+	// RetryDelay delay after first failed attempt, doubled after every next one up to MaxRetryDelay
+	RetryDelay    time.Duration `env:"WEBHOOK_RETRY_DELAY" envDefault:"30s"`
+	MaxRetryDelay time.Duration `env:"WEBHOOK_MAX_RETRY_DELAY" envDefault:"1h"`
+	// DisableAfter consecutive failed attempts after which subscription is disabled
+	DisableAfter int `env:"WEBHOOK_DISABLE_AFTER" envDefault:"20"`
+}
+
+// NewWebhookConfig creates new WebhookConfig object
+func NewWebhookConfig() (*WebhookConfig, error) {
+	cfg := new(WebhookConfig)
+	err := env.Parse(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
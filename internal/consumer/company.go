@@ -3,73 +3,66 @@ package consumer
 
 import (
 	"context"
-	"errors"
-	"fmt"
+	"time"
 
-	"github.com/go-redis/redis/v9"
-	"github.com/google/uuid"
-	log "github.com/sirupsen/logrus"
+	"github.com/Entetry/gocompany/internal/bus"
+	"github.com/Entetry/gocompany/internal/dlq"
+	"github.com/Entetry/gocompany/internal/event"
 )
 
 // Company consuming company messages
 type Company interface {
-	Consume(ctx context.Context, callbackFunc func(id uuid.UUID, action, name string))
+	Consume(ctx context.Context, callbackFunc func(position string, e event.Event))
+	Health() bus.Health
 }
 
-type redisCompany struct {
-	redis  *redis.Client
-	lastID string
+type company struct {
+	subscriber bus.Subscriber
+	opts       bus.SubscribeOptions
+	status     bus.Status
 }
 
-// NewRedisCompanyConsumer creates redis company consumer object
-func NewRedisCompanyConsumer(redisClient *redis.Client, startID string) Company {
-	return &redisCompany{
-		redis:  redisClient,
-		lastID: startID}
-}
-
-// Consume get message from redis stream
-func (c *redisCompany) Consume(ctx context.Context, callbackFunc func(id uuid.UUID, action, name string)) {
-	for {
-		args := &redis.XReadArgs{
-			Streams: []string{"company", c.lastID},
-		}
-		r, err := c.redis.XRead(ctx, args).Result()
-		if err != nil {
-			log.Error(err)
-		}
-
-		for _, message := range r[0].Messages {
-			id, action, name, decodeErr := decode(message)
-			if decodeErr != nil {
-				log.Error(err)
-			}
-
-			fmt.Printf("consumed message from redis: {%v, %s}\n", id, name)
-			callbackFunc(id, action, name)
-			c.lastID = message.ID
-		}
+// NewCompanyConsumer creates company consumer which gets all company messages after startID
+func NewCompanyConsumer(subscriber bus.Subscriber, deadLetter dlq.DeadLetter, startID string) Company {
+	return &company{
+		subscriber: subscriber,
+		opts: bus.SubscribeOptions{
+			From:       startID,
+			DeadLetter: deadLetter,
+		},
 	}
 }
 
-func decode(message redis.XMessage) (id uuid.UUID, action, name string, err error) {
-	action, ok := message.Values["event"].(string)
-	if !ok {
-		return id, action, name, errors.New("cannot convert action to string")
-	}
-	idStr, ok := message.Values["id"].(string)
-	if !ok {
-		return id, action, name, errors.New("cannot convert id to string")
-	}
-	name, ok = message.Values["name"].(string)
-	if !ok {
-		return id, action, name, errors.New("cannot convert name to string")
+// NewCompanyGroupConsumer creates company consumer which shares messages with other consumers of the group,
+// messages pending for longer than minIdle on other consumers are claimed
+func NewCompanyGroupConsumer(subscriber bus.Subscriber, deadLetter dlq.DeadLetter,
+	group, consumer string, minIdle time.Duration) Company {
+	return &company{
+		subscriber: subscriber,
+		opts: bus.SubscribeOptions{
+			Group:      group,
+			Consumer:   consumer,
+			MinIdle:    minIdle,
+			DeadLetter: deadLetter,
+		},
 	}
+}
 
-	id, err = uuid.Parse(idStr)
-	if err != nil {
-		return id, action, name, err
-	}
+// Consume get messages from company topic until ctx is canceled
+func (c *company) Consume(ctx context.Context, callbackFunc func(position string, e event.Event)) {
+	opts := c.opts
+	opts.Status = &c.status
+	c.subscriber.Subscribe(ctx, event.CompanyTopic, opts, func(ctx context.Context, message *bus.Message) error {
+		e, err := event.DecodeEnvelope(&message.Envelope)
+		if err != nil {
+			return bus.Permanent(err)
+		}
+		callbackFunc(message.Position, e)
+		return nil
+	})
+}
 
-	return id, action, name, nil
+// Health return health of company topic subscription
+func (c *company) Health() bus.Health {
+	return c.status.Health()
 }
@@ -0,0 +1,168 @@
+// Package dlq provides dead-letter stream for company messages which could not be consumed
+package dlq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+)
+
+const (
+	// CompanyStream dead-letter stream of company stream
+	CompanyStream = "company.dlq"
+
+	seenKeyPrefix = "company.dlq:seen:"
+	seenTTL       = 24 * time.Hour
+)
+
+// ErrNotFound dead-lettered message doesn't exist
+var ErrNotFound = errors.New("dead-lettered message not found")
+
+// Message dead-lettered message with original payload
+type Message struct {
+	ID        string            `json:"id"`
+	Stream    string            `json:"stream"`
+	Group     string            `json:"group"` // consumer group which failed message, empty for subscribers without group
+	MessageID string            `json:"messageID"`
+	Payload   map[string]string `json:"payload"`
+	Error     string            `json:"error"`
+	Attempts  int               `json:"attempts"`
+	FailedAt  time.Time         `json:"failedAt"`
+}
+
+// DeadLetter dead-letter stream interface
+type DeadLetter interface {
+	Add(ctx context.Context, message *Message) error
+	List(ctx context.Context, start string, count int64) ([]*Message, error)
+	Get(ctx context.Context, id string) (*Message, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type redisCompany struct {
+	redis  *redis.Client
+	maxLen int64
+	maxAge time.Duration
+}
+
+// NewRedisCompanyDeadLetter creates dead-letter stream for company messages, stream is trimmed on add to about
+// maxLen messages or, if maxLen is 0, to messages younger than maxAge. Zero values disable trimming.
+func NewRedisCompanyDeadLetter(redisClient *redis.Client, maxLen int64, maxAge time.Duration) DeadLetter {
+	return &redisCompany{redis: redisClient, maxLen: maxLen, maxAge: maxAge}
+}
+
+// addScript marks message as seen and adds it to dead-letter stream in one step,
+// so failed add doesn't leave message marked as already dead-lettered.
+// ARGV holds seen key ttl, trimming option, its threshold and message fields
+var addScript = redis.NewScript(`
+if not redis.call("SET", KEYS[1], 1, "NX", "EX", ARGV[1]) then
+	return false
+end
+if ARGV[2] == "" then
+	return redis.call("XADD", KEYS[2], "*", unpack(ARGV, 4))
+end
+return redis.call("XADD", KEYS[2], ARGV[2], "~", ARGV[3], "*", unpack(ARGV, 4))
+`)
+
+// Add puts message to dead-letter stream, message consumed by several consumers of the same group is stored once
+func (r *redisCompany) Add(ctx context.Context, message *Message) error {
+	payload, err := json.Marshal(message.Payload)
+	if err != nil {
+		return err
+	}
+	trim, threshold := r.trim()
+	id, err := addScript.Run(ctx, r.redis,
+		[]string{seenKeyPrefix + message.Stream + ":" + message.Group + ":" + message.MessageID, CompanyStream},
+		int64(seenTTL/time.Second), trim, threshold,
+		"stream", message.Stream,
+		"group", message.Group,
+		"messageID", message.MessageID,
+		"payload", string(payload),
+		"error", message.Error,
+		"attempts", message.Attempts,
+		"failedAt", message.FailedAt.UnixMilli(),
+	).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot dead-letter message %s: %v", message.MessageID, err)
+	}
+	message.ID = id
+	return nil
+}
+
+// trim returns XADD trimming option and its threshold, empty option if trimming is disabled
+func (r *redisCompany) trim() (string, string) {
+	switch {
+	case r.maxLen > 0:
+		return "MAXLEN", strconv.FormatInt(r.maxLen, 10)
+	case r.maxAge > 0:
+		return "MINID", fmt.Sprintf("%d-0", time.Now().Add(-r.maxAge).UnixMilli())
+	default:
+		return "", ""
+	}
+}
+
+// List returns up to count dead-lettered messages starting from id start
+func (r *redisCompany) List(ctx context.Context, start string, count int64) ([]*Message, error) {
+	if start == "" {
+		start = "-"
+	}
+	messages, err := r.redis.XRangeN(ctx, CompanyStream, start, "+", count).Result()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list dead-lettered messages: %v", err)
+	}
+	results := make([]*Message, 0, len(messages))
+	for _, message := range messages {
+		results = append(results, decode(message))
+	}
+	return results, nil
+}
+
+// Get return dead-lettered message by its id
+func (r *redisCompany) Get(ctx context.Context, id string) (*Message, error) {
+	messages, err := r.redis.XRangeN(ctx, CompanyStream, id, id, 1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get dead-lettered message: %v", err)
+	}
+	if len(messages) == 0 {
+		return nil, ErrNotFound
+	}
+	return decode(messages[0]), nil
+}
+
+// Delete removes message from dead-letter stream
+func (r *redisCompany) Delete(ctx context.Context, id string) error {
+	deleted, err := r.redis.XDel(ctx, CompanyStream, id).Result()
+	if err != nil {
+		return fmt.Errorf("cannot delete dead-lettered message %s: %v", id, err)
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func decode(message redis.XMessage) *Message {
+	result := &Message{ID: message.ID}
+	result.Stream, _ = message.Values["stream"].(string)
+	result.Group, _ = message.Values["group"].(string)
+	result.MessageID, _ = message.Values["messageID"].(string)
+	result.Error, _ = message.Values["error"].(string)
+	if payload, ok := message.Values["payload"].(string); ok {
+		_ = json.Unmarshal([]byte(payload), &result.Payload)
+	}
+	if attempts, ok := message.Values["attempts"].(string); ok {
+		result.Attempts, _ = strconv.Atoi(attempts)
+	}
+	if failedAt, ok := message.Values["failedAt"].(string); ok {
+		ms, _ := strconv.ParseInt(failedAt, 10, 64)
+		result.FailedAt = time.UnixMilli(ms)
+	}
+	return result
+}
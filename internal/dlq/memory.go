@@ -0,0 +1,105 @@
+package dlq
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type memory struct {
+	mu       sync.RWMutex
+	seq      int64
+	messages []*Message
+	seen     map[string]struct{}
+	// seenOrder seen keys in order they were added, keys are forgotten after seenTTL like in redis
+	seenOrder []seenKey
+}
+
+type seenKey struct {
+	key    string
+	seenAt time.Time
+}
+
+// NewMemoryDeadLetter creates in-process dead-letter storage for single node deployments
+func NewMemoryDeadLetter() DeadLetter {
+	return &memory{seen: make(map[string]struct{})}
+}
+
+// Add stores message, message consumed by several consumers of the same group is stored once
+func (m *memory) Add(_ context.Context, message *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.forget(now.Add(-seenTTL))
+	key := message.Stream + ":" + message.Group + ":" + message.MessageID
+	if _, ok := m.seen[key]; ok {
+		return nil
+	}
+	m.seen[key] = struct{}{}
+	m.seenOrder = append(m.seenOrder, seenKey{key: key, seenAt: now})
+	m.seq++
+	stored := *message
+	stored.ID = strconv.FormatInt(m.seq, 10)
+	message.ID = stored.ID
+	m.messages = append(m.messages, &stored)
+	return nil
+}
+
+// forget drops keys seen before, must be called with lock held
+func (m *memory) forget(before time.Time) {
+	expired := 0
+	for expired < len(m.seenOrder) && m.seenOrder[expired].seenAt.Before(before) {
+		delete(m.seen, m.seenOrder[expired].key)
+		expired++
+	}
+	m.seenOrder = m.seenOrder[expired:]
+}
+
+// List returns up to count dead-lettered messages starting from id start
+func (m *memory) List(_ context.Context, start string, count int64) ([]*Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	from, _ := strconv.ParseInt(start, 10, 64)
+	results := make([]*Message, 0)
+	for _, message := range m.messages {
+		id, _ := strconv.ParseInt(message.ID, 10, 64)
+		if id < from {
+			continue
+		}
+		if int64(len(results)) >= count {
+			break
+		}
+		results = append(results, message)
+	}
+	return results, nil
+}
+
+// Get return dead-lettered message by its id
+func (m *memory) Get(_ context.Context, id string) (*Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, message := range m.messages {
+		if message.ID == id {
+			return message, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Delete removes dead-lettered message
+func (m *memory) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, message := range m.messages {
+		if message.ID == id {
+			m.messages = append(m.messages[:i], m.messages[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
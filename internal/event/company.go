@@ -1,9 +1,303 @@
-// Package event contains redis stream events
+// Package event contains versioned company event contracts
 package event
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Entetry/gocompany/internal/bus"
+)
+
+// CompanyTopic topic of company events
+const CompanyTopic = "company"
+
+// SchemaVersion current version of company events.
+// Version 0 events are flat {id, event, name} messages, version 1 payload is {name},
+// since version 2 payload is the event struct itself.
+const SchemaVersion = 2
+
 const (
-	// UPDATE redis action for add or update entry in cache
+	// CREATE type of CompanyCreated event
+	CREATE = "CREATE"
+	// UPDATE type of CompanyUpdated event
 	UPDATE = "UPDATE"
-	// DELETE redis action for delete from cache
+	// DELETE type of CompanyDeleted event
 	DELETE = "DELETE"
+	// LOGO_ADDED type of LogoAdded event
+	LOGO_ADDED = "LOGO_ADDED" //nolint:revive,stylecheck
+	// LOGO_REPLACED type of LogoReplaced event
+	LOGO_REPLACED = "LOGO_REPLACED" //nolint:revive,stylecheck
+	// LOGO_DELETED type of LogoDeleted event
+	LOGO_DELETED = "LOGO_DELETED" //nolint:revive,stylecheck
 )
+
+var (
+	// ErrUnknownType event type has no contract
+	ErrUnknownType = errors.New("unknown event type")
+	// ErrUnsupportedVersion event was published with newer schema than this build knows
+	ErrUnsupportedVersion = errors.New("unsupported event schema version")
+)
+
+// Event company event contract
+type Event interface {
+	Type() string
+	AggregateID() uuid.UUID
+	setVersion(version int)
+	validate() error
+}
+
+// Schema versioned part of every company event
+type Schema struct {
+	SchemaVersion int `json:"schemaVersion"`
+}
+
+func (s *Schema) setVersion(version int) {
+	s.SchemaVersion = version
+}
+
+// CompanyCreated company was created
+type CompanyCreated struct {
+	Schema
+	CompanyID uuid.UUID `json:"companyID"`
+	Name      string    `json:"name"`
+}
+
+// Type return event type
+func (e *CompanyCreated) Type() string { return CREATE }
+
+// AggregateID return company id
+func (e *CompanyCreated) AggregateID() uuid.UUID { return e.CompanyID }
+
+func (e *CompanyCreated) validate() error {
+	return validateNamed(e.CompanyID, e.Name)
+}
+
+// CompanyUpdated company was renamed or read into cache
+type CompanyUpdated struct {
+	Schema
+	CompanyID uuid.UUID `json:"companyID"`
+	Name      string    `json:"name"`
+}
+
+// Type return event type
+func (e *CompanyUpdated) Type() string { return UPDATE }
+
+// AggregateID return company id
+func (e *CompanyUpdated) AggregateID() uuid.UUID { return e.CompanyID }
+
+func (e *CompanyUpdated) validate() error {
+	return validateNamed(e.CompanyID, e.Name)
+}
+
+// CompanyDeleted company was deleted
+type CompanyDeleted struct {
+	Schema
+	CompanyID uuid.UUID `json:"companyID"`
+}
+
+// Type return event type
+func (e *CompanyDeleted) Type() string { return DELETE }
+
+// AggregateID return company id
+func (e *CompanyDeleted) AggregateID() uuid.UUID { return e.CompanyID }
+
+func (e *CompanyDeleted) validate() error {
+	return validateCompanyID(e.CompanyID)
+}
+
+// LogoAdded logo was uploaded for company
+type LogoAdded struct {
+	Schema
+	CompanyID uuid.UUID `json:"companyID"`
+	LogoID    uuid.UUID `json:"logoID"`
+}
+
+// Type return event type
+func (e *LogoAdded) Type() string { return LOGO_ADDED }
+
+// AggregateID return company id
+func (e *LogoAdded) AggregateID() uuid.UUID { return e.CompanyID }
+
+func (e *LogoAdded) validate() error {
+	if e.LogoID == uuid.Nil {
+		return errors.New("logoID is required")
+	}
+	return validateCompanyID(e.CompanyID)
+}
+
+// LogoReplaced company logo was replaced, caches of previous logo must be invalidated
+type LogoReplaced struct {
+	Schema
+	CompanyID      uuid.UUID `json:"companyID"`
+	LogoID         uuid.UUID `json:"logoID"`
+	PreviousLogoID uuid.UUID `json:"previousLogoID"`
+}
+
+// Type return event type
+func (e *LogoReplaced) Type() string { return LOGO_REPLACED }
+
+// AggregateID return company id
+func (e *LogoReplaced) AggregateID() uuid.UUID { return e.CompanyID }
+
+func (e *LogoReplaced) validate() error {
+	if e.LogoID == uuid.Nil || e.PreviousLogoID == uuid.Nil {
+		return errors.New("logoID and previousLogoID are required")
+	}
+	return validateCompanyID(e.CompanyID)
+}
+
+// LogoDeleted company logo was deleted
+type LogoDeleted struct {
+	Schema
+	CompanyID uuid.UUID `json:"companyID"`
+	LogoID    uuid.UUID `json:"logoID"`
+}
+
+// Type return event type
+func (e *LogoDeleted) Type() string { return LOGO_DELETED }
+
+// AggregateID return company id
+func (e *LogoDeleted) AggregateID() uuid.UUID { return e.CompanyID }
+
+func (e *LogoDeleted) validate() error {
+	if e.LogoID == uuid.Nil {
+		return errors.New("logoID is required")
+	}
+	return validateCompanyID(e.CompanyID)
+}
+
+// Encode marshals event with current schema version, e itself is left unchanged
+func Encode(e Event) (json.RawMessage, error) {
+	e = versioned(e, SchemaVersion)
+	err := e.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s event: %v", e.Type(), err)
+	}
+	return json.Marshal(e)
+}
+
+// versioned returns copy of event with schema version set, events are pointers to structs
+func versioned(e Event, version int) Event {
+	original := reflect.ValueOf(e).Elem()
+	copied := reflect.New(original.Type())
+	copied.Elem().Set(original)
+	result := copied.Interface().(Event)
+	result.setVersion(version)
+	return result
+}
+
+// NewEnvelope wraps event into bus envelope
+func NewEnvelope(e Event) (*bus.Envelope, error) {
+	payload, err := Encode(e)
+	if err != nil {
+		return nil, err
+	}
+	return &bus.Envelope{
+		ID:          uuid.New(),
+		Type:        e.Type(),
+		AggregateID: e.AggregateID(),
+		Version:     SchemaVersion,
+		Timestamp:   time.Now(),
+		Payload:     payload,
+	}, nil
+}
+
+// Decode parses payload of any known schema version and upgrades it to current one
+func Decode(eventType string, version int, aggregateID uuid.UUID, payload []byte) (Event, error) {
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("%w: %s version %d", ErrUnsupportedVersion, eventType, version)
+	}
+	e, err := newEvent(eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	if version < 2 {
+		err = decodeV1(e, aggregateID, payload)
+	} else {
+		err = json.Unmarshal(payload, e)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode %s event version %d: %v", eventType, version, err)
+	}
+	e.setVersion(SchemaVersion)
+	err = e.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s event version %d: %v", eventType, version, err)
+	}
+	return e, nil
+}
+
+// DecodeEnvelope parses payload of bus envelope
+func DecodeEnvelope(envelope *bus.Envelope) (Event, error) {
+	return Decode(envelope.Type, envelope.Version, envelope.AggregateID, envelope.Payload)
+}
+
+// Known checks if event type has contract
+func Known(eventType string) bool {
+	_, err := newEvent(eventType)
+	return err == nil
+}
+
+func newEvent(eventType string) (Event, error) {
+	switch eventType {
+	case CREATE:
+		return new(CompanyCreated), nil
+	case UPDATE:
+		return new(CompanyUpdated), nil
+	case DELETE:
+		return new(CompanyDeleted), nil
+	case LOGO_ADDED:
+		return new(LogoAdded), nil
+	case LOGO_REPLACED:
+		return new(LogoReplaced), nil
+	case LOGO_DELETED:
+		return new(LogoDeleted), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, eventType)
+	}
+}
+
+// decodeV1 fills event from {name} payload, company id came in envelope only
+func decodeV1(e Event, aggregateID uuid.UUID, payload []byte) error {
+	var v1 struct {
+		Name *string `json:"name"`
+	}
+	err := json.Unmarshal(payload, &v1)
+	if err != nil {
+		return err
+	}
+	if v1.Name == nil {
+		return errors.New("name is missing")
+	}
+	switch e := e.(type) {
+	case *CompanyCreated:
+		e.CompanyID, e.Name = aggregateID, *v1.Name
+	case *CompanyUpdated:
+		e.CompanyID, e.Name = aggregateID, *v1.Name
+	case *CompanyDeleted:
+		e.CompanyID = aggregateID
+	default:
+		return fmt.Errorf("%s has no version 1", e.Type())
+	}
+	return nil
+}
+
+func validateCompanyID(companyID uuid.UUID) error {
+	if companyID == uuid.Nil {
+		return errors.New("companyID is required")
+	}
+	return nil
+}
+
+func validateNamed(companyID uuid.UUID, name string) error {
+	if name == "" {
+		return errors.New("name is required")
+	}
+	return validateCompanyID(companyID)
+}
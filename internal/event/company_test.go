@@ -0,0 +1,107 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	companyID = uuid.MustParse("6f1c2f3e-8f4a-4c55-9b7b-2f1f6f0c9a11")
+	logoID    = uuid.MustParse("0b5d3c8e-2a41-4a8e-8d0e-5c3f0f1b7e22")
+	newLogoID = uuid.MustParse("9d2e7a14-6b3c-4f0e-a5d8-1c7b4e2f3a90")
+)
+
+type contract struct {
+	golden string
+	event  Event
+}
+
+// contracts returns fresh events so tests can't affect each other through shared fixtures
+func contracts() []contract {
+	return []contract{
+		{"company_created.v2.json", &CompanyCreated{CompanyID: companyID, Name: "Google"}},
+		{"company_updated.v2.json", &CompanyUpdated{CompanyID: companyID, Name: "Alphabet"}},
+		{"company_deleted.v2.json", &CompanyDeleted{CompanyID: companyID}},
+		{"logo_added.v2.json", &LogoAdded{CompanyID: companyID, LogoID: logoID}},
+		{"logo_replaced.v2.json", &LogoReplaced{CompanyID: companyID, LogoID: newLogoID, PreviousLogoID: logoID}},
+		{"logo_deleted.v2.json", &LogoDeleted{CompanyID: companyID, LogoID: newLogoID}},
+	}
+}
+
+func readGolden(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err, "cannot read golden file")
+	return data
+}
+
+// TestContract_Encode fails when a field of published contract is removed, renamed or changes its value
+func TestContract_Encode(t *testing.T) {
+	t.Log("Given the need to test encoded events keep every field of their contracts.")
+	for _, contract := range contracts() {
+		var golden map[string]interface{}
+		err := json.Unmarshal(readGolden(t, contract.golden), &golden)
+		require.NoError(t, err)
+
+		payload, err := Encode(contract.event)
+		require.NoError(t, err, "tested encode function error")
+		require.Zero(t, versionOf(contract.event), "%s: encode changed its argument", contract.golden)
+		var encoded map[string]interface{}
+		err = json.Unmarshal(payload, &encoded)
+		require.NoError(t, err)
+
+		for field, value := range golden {
+			require.Contains(t, encoded, field, "%s: field %s was removed from contract", contract.golden, field)
+			require.Equal(t, value, encoded[field], "%s: field %s changed", contract.golden, field)
+		}
+	}
+}
+
+func TestContract_Decode(t *testing.T) {
+	t.Log("Given the need to test published contracts are decoded.")
+	for _, contract := range contracts() {
+		e, err := Decode(contract.event.Type(), SchemaVersion, companyID, readGolden(t, contract.golden))
+		require.NoError(t, err, "tested decode function error")
+		require.Equal(t, versioned(contract.event, 2), e)
+	}
+}
+
+func versionOf(e Event) int {
+	payload, _ := json.Marshal(e)
+	var schema Schema
+	_ = json.Unmarshal(payload, &schema)
+	return schema.SchemaVersion
+}
+
+func TestDecode_OlderVersions(t *testing.T) {
+	t.Log("Given the need to test events published with older schema versions are upgraded.")
+	for _, version := range []int{0, 1} {
+		e, err := Decode(CREATE, version, companyID, []byte(`{"name":"Google"}`))
+		require.NoError(t, err, "version %d", version)
+		require.Equal(t, &CompanyCreated{Schema: Schema{SchemaVersion}, CompanyID: companyID, Name: "Google"}, e)
+
+		e, err = Decode(DELETE, version, companyID, []byte(`{"name":""}`))
+		require.NoError(t, err, "version %d", version)
+		require.Equal(t, &CompanyDeleted{Schema: Schema{SchemaVersion}, CompanyID: companyID}, e)
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	t.Log("Given the need to test events breaking contracts are rejected.")
+	_, err := Decode(CREATE, SchemaVersion+1, companyID, readGolden(t, "company_created.v2.json"))
+	require.True(t, errors.Is(err, ErrUnsupportedVersion))
+
+	_, err = Decode("RENAME", SchemaVersion, companyID, []byte(`{}`))
+	require.True(t, errors.Is(err, ErrUnknownType))
+
+	_, err = Decode(CREATE, SchemaVersion, companyID, []byte(`{"schemaVersion":2,"companyID":"`+companyID.String()+`"}`))
+	require.Error(t, err, "name is required")
+
+	_, err = Decode(LOGO_ADDED, 1, companyID, []byte(`{"name":"Google"}`))
+	require.Error(t, err, "logo events have no version 1")
+}
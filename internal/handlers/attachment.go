@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/service"
+)
+
+const (
+	// uploadOffsetHeader offset chunk starts at in request, offset upload stopped at in response
+	uploadOffsetHeader = "Upload-Offset"
+	// uploadLengthHeader declared size of whole attachment
+	uploadLengthHeader = "Upload-Length"
+	// uploadChecksumHeader checksum of chunk as "sha256 <base64 digest>"
+	uploadChecksumHeader = "Upload-Checksum"
+	// statusChecksumMismatch response status of chunk not matching its checksum
+	statusChecksumMismatch = 460
+)
+
+// Attachment handler of company attachments, uploads follow tus protocol: upload is created with its size,
+// chunks are sent by PATCH with Upload-Offset and HEAD tells offset to resume from after failure
+type Attachment struct {
+	attachmentService *service.Attachment
+}
+
+// NewAttachment creates new Attachment handler
+func NewAttachment(attachmentService *service.Attachment) *Attachment {
+	return &Attachment{attachmentService: attachmentService}
+}
+
+// Create godoc
+// @Summary start upload of company attachment
+// @Accept  json
+// @Produce json
+// @Param   input body createAttachmentRequest true "attachment"
+// @Success 201 {object} attachmentResponse
+// @Failure 400
+// @Failure 404
+// @Failure 413
+// @Failure 500
+// @Router  /company/{id}/attachments [post]
+func (a *Attachment) Create(ctx echo.Context) error {
+	companyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	request := new(createAttachmentRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = ctx.Validate(request)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	attachment, err := a.attachmentService.Create(ctx.Request().Context(), companyID, request.FileName,
+		request.ContentType, request.Size)
+	if err != nil {
+		return attachmentError(err)
+	}
+	ctx.Response().Header().Set(echo.HeaderLocation, ctx.Request().URL.Path+"/"+attachment.ID.String())
+	return ctx.JSON(http.StatusCreated, newAttachmentResponse(attachment))
+}
+
+// GetAll godoc
+// @Summary Retrieves all attachments of company including unfinished uploads
+// @Produce json
+// @Success 200 {array} attachmentResponse
+// @Failure 400
+// @Failure 500
+// @Router  /company/{id}/attachments [get]
+func (a *Attachment) GetAll(ctx echo.Context) error {
+	companyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	attachments, err := a.attachmentService.GetAll(ctx.Request().Context(), companyID)
+	if err != nil {
+		return attachmentError(err)
+	}
+	response := make([]*attachmentResponse, 0, len(attachments))
+	for _, attachment := range attachments {
+		response = append(response, newAttachmentResponse(attachment))
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// Get godoc
+// @Summary Retrieves company attachment
+// @Produce json
+// @Success 200 {object} attachmentResponse
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/attachments/{attachmentID} [get]
+func (a *Attachment) Get(ctx echo.Context) error {
+	companyID, id, err := parseAttachmentID(ctx)
+	if err != nil {
+		return err
+	}
+	attachment, err := a.attachmentService.Get(ctx.Request().Context(), companyID, id)
+	if err != nil {
+		return attachmentError(err)
+	}
+	return ctx.JSON(http.StatusOK, newAttachmentResponse(attachment))
+}
+
+// Head godoc
+// @Summary Retrieves offset to resume attachment upload from
+// @Success 200
+// @Header  200 {integer} Upload-Offset "uploaded bytes"
+// @Header  200 {integer} Upload-Length "attachment size"
+// @Failure 400
+// @Failure 404
+// @Router  /company/{id}/attachments/{attachmentID} [head]
+func (a *Attachment) Head(ctx echo.Context) error {
+	companyID, id, err := parseAttachmentID(ctx)
+	if err != nil {
+		return err
+	}
+	attachment, err := a.attachmentService.Get(ctx.Request().Context(), companyID, id)
+	if err != nil {
+		return attachmentError(err)
+	}
+	ctx.Response().Header().Set(uploadOffsetHeader, strconv.FormatInt(attachment.Offset, 10))
+	ctx.Response().Header().Set(uploadLengthHeader, strconv.FormatInt(attachment.Size, 10))
+	ctx.Response().Header().Set("Cache-Control", "no-store")
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Upload godoc
+// @Summary upload next chunk of attachment
+// @Description Chunk must start at current upload offset and match its sha256 checksum.
+// @Accept  application/offset+octet-stream
+// @Param   Upload-Offset header int true "offset chunk starts at"
+// @Param   Upload-Checksum header string true "sha256 <base64 digest of chunk>"
+// @Success 204
+// @Header  204 {integer} Upload-Offset "uploaded bytes"
+// @Failure 400
+// @Failure 404
+// @Failure 409
+// @Failure 413
+// @Failure 460
+// @Failure 500
+// @Router  /company/{id}/attachments/{attachmentID} [patch]
+func (a *Attachment) Upload(ctx echo.Context) error {
+	companyID, id, err := parseAttachmentID(ctx)
+	if err != nil {
+		return err
+	}
+	offset, err := strconv.ParseInt(ctx.Request().Header.Get(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Upload-Offset must be a non-negative number")
+	}
+	algorithm, digest, _ := strings.Cut(ctx.Request().Header.Get(uploadChecksumHeader), " ")
+	checksum, err := base64.StdEncoding.DecodeString(digest)
+	if algorithm != "sha256" || err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Upload-Checksum must be sha256 with base64 digest")
+	}
+
+	attachment, err := a.attachmentService.AppendChunk(ctx.Request().Context(), companyID, id, offset, checksum,
+		ctx.Request().Body)
+	if attachment != nil {
+		ctx.Response().Header().Set(uploadOffsetHeader, strconv.FormatInt(attachment.Offset, 10))
+	}
+	if err != nil {
+		return attachmentError(err)
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// Download godoc
+// @Summary download completely uploaded attachment
+// @Description Supports Range requests, so interrupted downloads can be resumed
+// @Success 200
+// @Success 206
+// @Failure 400
+// @Failure 404
+// @Failure 409
+// @Failure 500
+// @Router  /company/{id}/attachments/{attachmentID}/content [get]
+func (a *Attachment) Download(ctx echo.Context) error {
+	companyID, id, err := parseAttachmentID(ctx)
+	if err != nil {
+		return err
+	}
+	file, err := a.attachmentService.Open(ctx.Request().Context(), companyID, id)
+	if err != nil {
+		return attachmentError(err)
+	}
+	defer func() {
+		if closeErr := file.Content.Close(); closeErr != nil {
+			log.Error(closeErr)
+		}
+	}()
+	header := ctx.Response().Header()
+	header.Set(echo.HeaderContentType, file.ContentType)
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment",
+		map[string]string{"filename": file.FileName}))
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("ETag", `"`+file.Checksum+`"`)
+	http.ServeContent(ctx.Response(), ctx.Request(), "", time.Time{}, file.Content)
+	return nil
+}
+
+func parseAttachmentID(ctx echo.Context) (uuid.UUID, uuid.UUID, error) {
+	companyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusBadRequest)
+	}
+	id, err := uuid.Parse(ctx.Param("attachmentID"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, echo.NewHTTPError(http.StatusBadRequest)
+	}
+	return companyID, id, nil
+}
+
+func attachmentError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrAttachmentNotFound), errors.Is(err, service.ErrCompanyNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrAttachmentTooLarge), errors.Is(err, service.ErrChunkTooLarge):
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, service.ErrOffsetMismatch), errors.Is(err, service.ErrUploadIncomplete):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrChecksumMismatch):
+		return echo.NewHTTPError(statusChecksumMismatch, err.Error())
+	case errors.Is(err, service.ErrInvalidAttachment), errors.Is(err, service.ErrEmptyChunk):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
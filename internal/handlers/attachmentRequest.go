@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+type createAttachmentRequest struct {
+	FileName    string `json:"fileName" validate:"required"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size" validate:"required,gt=0"`
+}
+
+type attachmentResponse struct {
+	ID          uuid.UUID `json:"id"`
+	FileName    string    `json:"fileName"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	Offset      int64     `json:"offset"`
+	Complete    bool      `json:"complete"`
+	// Checksum hex sha256 of whole file, set once upload is complete
+	Checksum    string     `json:"checksum,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+func newAttachmentResponse(attachment *model.Attachment) *attachmentResponse {
+	return &attachmentResponse{
+		ID:          attachment.ID,
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		Offset:      attachment.Offset,
+		Complete:    attachment.Complete(),
+		Checksum:    attachment.Checksum,
+		CreatedAt:   attachment.CreatedAt,
+		CompletedAt: attachment.CompletedAt,
+	}
+}
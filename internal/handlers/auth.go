@@ -8,6 +8,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/password"
 	"github.com/Entetry/gocompany/internal/service"
 )
 
@@ -29,6 +30,7 @@ func NewAuth(authService *service.Auth) *Auth {
 // @Param   input body     signInRequest true "username and password"
 // @Success 200   {object} tokenResponse "AccessToken  string and RefreshToken string"
 // @Failure 400
+// @Failure 403 "email is not verified"
 // @Failure 500
 // @Router  /auth/sign-in [post]
 func (a *Auth) SignIn(ctx echo.Context) error {
@@ -52,6 +54,9 @@ func (a *Auth) SignIn(ctx echo.Context) error {
 	}
 
 	refreshToken, accessToken, err := a.authService.SignIn(ctx.Request().Context(), request.Username, request.Password, tokenParam)
+	if errors.Is(err, service.ErrEmailNotVerified) {
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	}
 	if err != nil {
 		log.Error(err)
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
@@ -70,7 +75,7 @@ func (a *Auth) SignIn(ctx echo.Context) error {
 // @Produce json
 // @Param   input body signUpRequest true "username, email and password"
 // @Success 200
-// @Failure 400
+// @Failure 400 "invalid request or weak password, message lists broken password rules"
 // @Failure 500
 // @Router  /auth/sign-up [post]
 func (a *Auth) SignUp(ctx echo.Context) error {
@@ -88,6 +93,9 @@ func (a *Auth) SignUp(ctx echo.Context) error {
 	}
 
 	err = a.authService.SignUp(ctx.Request().Context(), request.Username, request.Password, request.Email)
+	if errors.Is(err, password.ErrWeakPassword) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 	if err != nil {
 		log.Error(err)
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
@@ -171,6 +179,139 @@ func (a *Auth) Logout(ctx echo.Context) error {
 	return ctx.NoContent(http.StatusOK)
 }
 
+// VerifyEmail godoc
+// @Summary verify email of signed up user
+// @Tags    auth
+// @Accept  json
+// @Param   input body verifyEmailRequest true "token from verification email"
+// @Success 204
+// @Failure 400
+// @Failure 500
+// @Router  /auth/verify-email [post]
+func (a *Auth) VerifyEmail(ctx echo.Context) error {
+	request := new(verifyEmailRequest)
+	err := ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = a.authService.VerifyEmail(ctx.Request().Context(), request.Token)
+	if errors.Is(err, service.ErrInvalidToken) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// ResendVerification godoc
+// @Summary     send verification email again
+// @Description Response is the same whether or not pending user with email exists
+// @Tags        auth
+// @Accept      json
+// @Param       input body resendVerificationRequest true "email"
+// @Success     202
+// @Failure     400
+// @Failure     500
+// @Router      /auth/resend-verification [post]
+func (a *Auth) ResendVerification(ctx echo.Context) error {
+	request := new(resendVerificationRequest)
+	err := ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = a.authService.ResendVerification(ctx.Request().Context(), request.Email)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+// ForgotPassword godoc
+// @Summary     send password reset email
+// @Description Response is the same whether or not user with email exists
+// @Tags        auth
+// @Accept      json
+// @Param       input body forgotPasswordRequest true "email"
+// @Success     202
+// @Failure     400
+// @Failure     500
+// @Router      /auth/password/forgot [post]
+func (a *Auth) ForgotPassword(ctx echo.Context) error {
+	request := new(forgotPasswordRequest)
+	err := ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = a.authService.ForgotPassword(ctx.Request().Context(), request.Email)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+// ResetPassword godoc
+// @Summary     set new password with token from password reset email
+// @Description All sessions of user are logged out
+// @Tags        auth
+// @Accept      json
+// @Param       input body resetPasswordRequest true "token and new password"
+// @Success     204
+// @Failure     400
+// @Failure     500
+// @Router      /auth/password/reset [post]
+func (a *Auth) ResetPassword(ctx echo.Context) error {
+	request := new(resetPasswordRequest)
+	err := ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = a.authService.ResetPassword(ctx.Request().Context(), request.Token, request.Password)
+	if errors.Is(err, service.ErrInvalidToken) || errors.Is(err, password.ErrWeakPassword) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
 func parseTokenParam(header http.Header) (*model.TokenParam, error) {
 	ua := header.Get("User-Agent")
 	fingerprint := header.Get("Fingerprint")
@@ -10,13 +10,31 @@ type logoutRequest struct {
 
 type signInRequest struct {
 	Username string `json:"username" validate:"required,gte=3,lte=32"`
-	Password string `json:"password" validate:"required,gte=5,lte=60"`
+	Password string `json:"password" validate:"required,lte=1024"`
 }
 
 type signUpRequest struct {
 	Username string `json:"username" validate:"required,gte=3,lte=32"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,gte=5,lte=60"`
+	// Password rules are checked by password policy
+	Password string `json:"password" validate:"required"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type resendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
 type tokenResponse struct {
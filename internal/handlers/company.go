@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/Entetry/gocompany/internal/imaging"
 	"github.com/Entetry/gocompany/internal/model"
 	"github.com/Entetry/gocompany/internal/service"
 )
@@ -137,9 +142,17 @@ func (c *Company) Delete(ctx echo.Context) error {
 
 // GetLogoByCompanyID godoc
 // @Summary Retrieves company logo based on given company ID
+// @Description Raster logos are resized to the rendition nearest to size, svg logos are always served as is.
+// @Description Logo ETag is hash of its content, url with v set to it is cached for good.
+// @Description Supports If-None-Match and Range requests.
 // @Produce json
+// @Param   size query int false "longer side of logo in pixels"
+// @Param   v query string false "logo ETag without quotes"
 // @Success 200
+// @Success 206
+// @Success 304
 // @Failure 400
+// @Failure 404
 // @Failure 500
 // @Router  /company/logo/{id} [get]
 func (c *Company) GetLogoByCompanyID(ctx echo.Context) error {
@@ -147,22 +160,144 @@ func (c *Company) GetLogoByCompanyID(ctx echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest)
 	}
+	size, err := parseLogoSize(ctx)
+	if err != nil {
+		return err
+	}
+	logo, err := c.companyService.GetLogo(ctx.Request().Context(), id, size)
+	if err != nil {
+		return logoError(err)
+	}
+	return serveLogo(ctx, logo, privateCacheControl(ctx, logo))
+}
 
-	logo, err := c.companyService.GetLogo(ctx.Request().Context(), id)
+// GetLogoVersions godoc
+// @Summary Retrieves all logo versions of company, latest first
+// @Produce json
+// @Success 200 {array} logoVersionResponse
+// @Failure 400
+// @Failure 500
+// @Router  /company/{id}/logo/versions [get]
+func (c *Company) GetLogoVersions(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
-		log.Error(err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	versions, err := c.companyService.GetLogoVersions(ctx.Request().Context(), id)
+	if err != nil {
+		return logoError(err)
+	}
+	response := make([]*logoVersionResponse, 0, len(versions))
+	for _, logo := range versions {
+		response = append(response, &logoVersionResponse{ID: logo.ID, Version: logo.Version,
+			ContentType: logo.ContentType, Current: logo.Current, CreatedAt: logo.CreatedAt})
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// GetLogoVersion godoc
+// @Summary Retrieves company logo version
+// @Description Served the same way as current logo, including renditions and caching headers
+// @Param   size query int false "longer side of logo in pixels"
+// @Param   v query string false "logo ETag without quotes"
+// @Success 200
+// @Success 206
+// @Success 304
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/logo/versions/{version} [get]
+func (c *Company) GetLogoVersion(ctx echo.Context) error {
+	id, version, err := parseLogoVersion(ctx)
+	if err != nil {
+		return err
+	}
+	size, err := parseLogoSize(ctx)
+	if err != nil {
+		return err
+	}
+	logo, err := c.companyService.GetLogoVersion(ctx.Request().Context(), id, version, size)
+	if err != nil {
+		return logoError(err)
+	}
+	return serveLogo(ctx, logo, privateCacheControl(ctx, logo))
+}
+
+// RollbackLogo godoc
+// @Summary make company logo version current again
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/logo/versions/{version}/rollback [post]
+func (c *Company) RollbackLogo(ctx echo.Context) error {
+	id, version, err := parseLogoVersion(ctx)
+	if err != nil {
+		return err
+	}
+	err = c.companyService.RollbackLogo(ctx.Request().Context(), id, version)
+	if err != nil {
+		return logoError(err)
+	}
+	return ctx.JSON(http.StatusOK, "Logo has been rolled back")
+}
+
+func parseLogoVersion(ctx echo.Context) (uuid.UUID, int, error) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, 0, echo.NewHTTPError(http.StatusBadRequest)
+	}
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil || version <= 0 {
+		return uuid.Nil, 0, echo.NewHTTPError(http.StatusBadRequest, "version must be a positive number")
+	}
+	return id, version, nil
+}
+
+func parseLogoSize(ctx echo.Context) (int, error) {
+	sizeParam := ctx.QueryParam("size")
+	if sizeParam == "" {
+		return 0, nil
+	}
+	size, err := strconv.Atoi(sizeParam)
+	if err != nil || size <= 0 {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "size must be a positive number")
 	}
-	if logo == "" {
-		return echo.NewHTTPError(http.StatusNotFound)
+	return size, nil
+}
+
+// privateCacheControl versioned url never changes content, any other one must be revalidated as logo can be
+// replaced
+func privateCacheControl(ctx echo.Context, logo *service.LogoFile) string {
+	if ctx.QueryParam("v") == strings.Trim(logo.ETag, `"`) {
+		return "private, max-age=31536000, immutable"
 	}
-	return ctx.File(logo)
+	return "private, no-cache"
+}
+
+// serveLogo writes logo with caching headers, ServeContent answers If-None-Match and Range requests using ETag
+func serveLogo(ctx echo.Context, logo *service.LogoFile, cacheControl string) error {
+	header := ctx.Response().Header()
+	header.Set(echo.HeaderContentType, logo.ContentType)
+	header.Set("ETag", logo.ETag)
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Cache-Control", cacheControl)
+	if logo.ContentType == imaging.SVG {
+		// svg is sanitized on upload, policy keeps it inert even if opened directly
+		header.Set(echo.HeaderContentSecurityPolicy, "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	}
+	http.ServeContent(ctx.Response(), ctx.Request(), "", time.Time{}, logo.Content)
+	return nil
 }
 
 // AddLogo godoc
 // @Summary add new company logo
+// @Description Accepts png, jpeg, gif, webp and svg images, svg is sanitized
 // @Produce mpfd
 // @Success 200
+// @Failure 400
+// @Failure 404
+// @Failure 413
 // @Failure 500
 // @Router  /company/logo [post]
 func (c *Company) AddLogo(ctx echo.Context) error {
@@ -174,8 +309,67 @@ func (c *Company) AddLogo(ctx echo.Context) error {
 	}
 	err = c.companyService.AddLogo(ctx.Request().Context(), companyID, file)
 	if err != nil {
+		return logoError(err)
+	}
+	return ctx.JSON(http.StatusOK, "Logo has been added")
+}
+
+// ReplaceLogo godoc
+// @Summary replace company logo, creates it if company has none, previous logo is kept as older version
+// @Description Accepts png, jpeg, gif, webp and svg images, svg is sanitized
+// @Produce mpfd
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Failure 413
+// @Failure 500
+// @Router  /company/{id}/logo [put]
+func (c *Company) ReplaceLogo(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	file, err := ctx.FormFile("image")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = c.companyService.ReplaceLogo(ctx.Request().Context(), id, file)
+	if err != nil {
+		return logoError(err)
+	}
+	return ctx.JSON(http.StatusOK, "Logo has been replaced")
+}
+
+// DeleteLogo godoc
+// @Summary delete current company logo, it's kept as older version
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router  /company/{id}/logo [delete]
+func (c *Company) DeleteLogo(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	err = c.companyService.DeleteLogo(ctx.Request().Context(), id)
+	if err != nil {
+		return logoError(err)
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+func logoError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrLogoNotFound), errors.Is(err, service.ErrLogoVersionNotFound),
+		errors.Is(err, service.ErrCompanyNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, imaging.ErrTooLarge):
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, imaging.ErrUnsupportedFormat), errors.Is(err, imaging.ErrInvalidImage):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
 		log.Error(err)
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
-	return ctx.JSON(http.StatusOK, "Logo has been added")
 }
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/bus"
+	"github.com/Entetry/gocompany/internal/event"
+	"github.com/Entetry/gocompany/internal/service"
+)
+
+// CompanyEvents handler company change feed struct
+type CompanyEvents struct {
+	companyFeed *service.CompanyFeed
+	heartbeat   time.Duration
+}
+
+// NewCompanyEvents creates new company change feed handler, idle streams get a comment every heartbeat
+func NewCompanyEvents(companyFeed *service.CompanyFeed, heartbeat time.Duration) *CompanyEvents {
+	return &CompanyEvents{companyFeed: companyFeed, heartbeat: heartbeat}
+}
+
+// Stream godoc
+// @Summary Streams company create, update and delete events as server-sent events
+// @Description Slow clients are disconnected and should reconnect with Last-Event-ID.
+// @Produce text/event-stream
+// @Param   Last-Event-ID header string false "id of the last received event, stream resumes after it"
+// @Success 200
+// @Failure 400
+// @Router  /company/events [get]
+func (c *CompanyEvents) Stream(ctx echo.Context) error {
+	lastEventID := ctx.Request().Header.Get("Last-Event-ID")
+	if lastEventID != "" {
+		if _, err := bus.ParsePosition(lastEventID); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	subscription := c.companyFeed.Subscribe(isCompanyChange)
+	defer c.companyFeed.Unsubscribe(subscription)
+
+	response := ctx.Response()
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set(echo.HeaderCacheControl, "no-cache")
+	response.Header().Set(echo.HeaderConnection, "keep-alive")
+	// disables response buffering of nginx
+	response.Header().Set("X-Accel-Buffering", "no")
+	response.WriteHeader(http.StatusOK)
+	response.Flush()
+
+	send := func(e *service.FeedEvent) error {
+		_, err := fmt.Fprintf(response, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+		if err != nil {
+			return err
+		}
+		response.Flush()
+		return nil
+	}
+
+	requestCtx := ctx.Request().Context()
+	if lastEventID != "" {
+		err := c.companyFeed.Replay(requestCtx, subscription, lastEventID, send)
+		if err != nil {
+			log.Errorf("cannot replay company events after %s: %v", lastEventID, err)
+			return nil
+		}
+	}
+
+	heartbeat := time.NewTicker(c.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-requestCtx.Done():
+			return nil
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(response, ": heartbeat\n\n")
+			if err != nil {
+				return nil
+			}
+			response.Flush()
+		case e, ok := <-subscription.Events():
+			if !ok {
+				// client fell behind, it resumes from the last received id on reconnect
+				return nil
+			}
+			if subscription.Replayed(e) {
+				continue
+			}
+			if send(e) != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func isCompanyChange(e *service.FeedEvent) bool {
+	return e.Type == event.CREATE || e.Type == event.UPDATE || e.Type == event.DELETE
+}
@@ -1,7 +1,11 @@
 // Package handlers Contains rest handlers
 package handlers
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type addCompanyRequest struct {
 	Name string `json:"name" validate:"required"`
@@ -11,3 +15,23 @@ type updateCompanyRequest struct {
 	UUID uuid.UUID `json:"uuid" validate:"required"`
 	Name string    `json:"name" validate:"required"`
 }
+
+type logoVersionResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Version     int       `json:"version"`
+	ContentType string    `json:"contentType"`
+	Current     bool      `json:"current"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type issueLogoURLRequest struct {
+	// TTL lifetime of url in seconds, default one if not set
+	TTL     int `json:"ttl" validate:"gte=0"`
+	Version int `json:"version" validate:"gte=0"`
+	Size    int `json:"size" validate:"gte=0"`
+}
+
+type logoURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
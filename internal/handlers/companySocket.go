@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+
+	"github.com/Entetry/gocompany/internal/service"
+)
+
+// CompanySocket handler websocket subscriptions to events of chosen companies struct
+type CompanySocket struct {
+	companyFeed  *service.CompanyFeed
+	heartbeat    time.Duration
+	maxCompanies int
+}
+
+// NewCompanySocket creates new company websocket handler, every connection may watch up to maxCompanies companies
+func NewCompanySocket(companyFeed *service.CompanyFeed, heartbeat time.Duration, maxCompanies int) *CompanySocket {
+	return &CompanySocket{companyFeed: companyFeed, heartbeat: heartbeat, maxCompanies: maxCompanies}
+}
+
+// Serve godoc
+// @Summary Pushes events of subscribed companies over websocket
+// @Description Client sends {"action": "subscribe"|"unsubscribe", "companyIDs": [...]} and receives
+// @Description messages of type subscribed, event, heartbeat and error. Slow clients are disconnected.
+// @Param   token query string false "access token, for clients which cannot set Authorization header"
+// @Success 101
+// @Failure 401
+// @Router  /company/ws [get]
+func (c *CompanySocket) Serve(ctx echo.Context) error {
+	// origin isn't checked, connection is authorized by token which browsers don't attach on their own
+	websocket.Server{Handler: c.serve}.ServeHTTP(ctx.Response(), ctx.Request())
+	return nil
+}
+
+func (c *CompanySocket) serve(conn *websocket.Conn) {
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Error(err)
+		}
+	}()
+	ctx, cancel := context.WithCancel(conn.Request().Context())
+	defer cancel()
+
+	companies := &companySet{ids: make(map[uuid.UUID]struct{})}
+	subscription := c.companyFeed.Subscribe(companies.contains)
+	defer c.companyFeed.Unsubscribe(subscription)
+
+	replies := make(chan *companySocketMessage)
+	go c.receive(ctx, cancel, conn, companies, replies)
+
+	heartbeat := time.NewTicker(c.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		var message *companySocketMessage
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			message = &companySocketMessage{Type: socketHeartbeat}
+		case message = <-replies:
+		case e, ok := <-subscription.Events():
+			if !ok {
+				c.send(conn, &companySocketMessage{Type: socketError, Error: "client is too slow"})
+				return
+			}
+			message = &companySocketMessage{
+				Type:      socketEvent,
+				ID:        e.ID,
+				Event:     e.Type,
+				CompanyID: &e.CompanyID,
+				Data:      e.Data,
+			}
+		}
+		if !c.send(conn, message) {
+			return
+		}
+	}
+}
+
+// receive handles client requests until connection is closed
+func (c *CompanySocket) receive(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn,
+	companies *companySet, replies chan<- *companySocketMessage) {
+	defer cancel()
+	for {
+		var raw []byte
+		err := websocket.Message.Receive(conn, &raw)
+		if err != nil {
+			return
+		}
+		reply := c.handle(raw, companies)
+		select {
+		case replies <- reply:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *CompanySocket) handle(raw []byte, companies *companySet) *companySocketMessage {
+	var request companySocketRequest
+	err := json.Unmarshal(raw, &request)
+	if err != nil {
+		return &companySocketMessage{Type: socketError, Error: err.Error()}
+	}
+	var ids []uuid.UUID
+	switch request.Action {
+	case socketSubscribe:
+		ids, err = companies.add(request.CompanyIDs, c.maxCompanies)
+	case socketUnsubscribe:
+		ids = companies.remove(request.CompanyIDs)
+	default:
+		err = fmt.Errorf("unknown action %q", request.Action)
+	}
+	if err != nil {
+		return &companySocketMessage{Type: socketError, Error: err.Error()}
+	}
+	return &companySocketMessage{Type: socketSubscribed, CompanyIDs: ids}
+}
+
+// send writes message, client which doesn't read it within heartbeat is dropped
+func (c *CompanySocket) send(conn *websocket.Conn, message *companySocketMessage) bool {
+	err := conn.SetWriteDeadline(time.Now().Add(c.heartbeat))
+	if err == nil {
+		err = websocket.JSON.Send(conn, message)
+	}
+	return err == nil
+}
+
+// companySet companies watched by single connection
+type companySet struct {
+	mu  sync.RWMutex
+	ids map[uuid.UUID]struct{}
+}
+
+func (s *companySet) contains(e *service.FeedEvent) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.ids[e.CompanyID]
+	return ok
+}
+
+func (s *companySet) add(ids []uuid.UUID, limit int) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	added := make(map[uuid.UUID]struct{})
+	for _, id := range ids {
+		if _, ok := s.ids[id]; !ok {
+			added[id] = struct{}{}
+		}
+	}
+	if len(s.ids)+len(added) > limit {
+		return nil, fmt.Errorf("cannot watch more than %d companies", limit)
+	}
+	for _, id := range ids {
+		s.ids[id] = struct{}{}
+	}
+	return s.list(), nil
+}
+
+func (s *companySet) remove(ids []uuid.UUID) []uuid.UUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.ids, id)
+	}
+	return s.list()
+}
+
+func (s *companySet) list() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(s.ids))
+	for id := range s.ids {
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+const (
+	socketSubscribe   = "subscribe"
+	socketUnsubscribe = "unsubscribe"
+
+	socketSubscribed = "subscribed"
+	socketEvent      = "event"
+	socketHeartbeat  = "heartbeat"
+	socketError      = "error"
+)
+
+// companySocketRequest message sent by websocket client
+type companySocketRequest struct {
+	// Action subscribe or unsubscribe
+	Action     string      `json:"action"`
+	CompanyIDs []uuid.UUID `json:"companyIDs"`
+}
+
+// companySocketMessage message sent to websocket client
+type companySocketMessage struct {
+	// Type subscribed, event, heartbeat or error
+	Type string `json:"type"`
+	// CompanyIDs all companies client is subscribed to, sent on subscribed
+	CompanyIDs []uuid.UUID `json:"companyIDs,omitempty"`
+	// ID, Event, CompanyID and Data describe company event
+	ID        string          `json:"id,omitempty"`
+	Event     string          `json:"event,omitempty"`
+	CompanyID *uuid.UUID      `json:"companyID,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
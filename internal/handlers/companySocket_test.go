@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+
+	"github.com/Entetry/gocompany/internal/bus"
+	"github.com/Entetry/gocompany/internal/event"
+	"github.com/Entetry/gocompany/internal/service"
+)
+
+// dialCompanySocket serves company socket on test server and connects to it
+func dialCompanySocket(t *testing.T, feed *service.CompanyFeed, heartbeat time.Duration,
+	maxCompanies int) *websocket.Conn {
+	router := echo.New()
+	router.GET("/company/ws", NewCompanySocket(feed, heartbeat, maxCompanies).Serve)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/company/ws", "", server.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func sendSocketRequest(t *testing.T, conn *websocket.Conn, action string, ids ...uuid.UUID) {
+	require.NoError(t, websocket.JSON.Send(conn, &companySocketRequest{Action: action, CompanyIDs: ids}))
+}
+
+// receiveSocketMessage reads next message skipping heartbeats
+func receiveSocketMessage(t *testing.T, conn *websocket.Conn) *companySocketMessage {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	for {
+		message := new(companySocketMessage)
+		require.NoError(t, websocket.JSON.Receive(conn, message))
+		if message.Type != socketHeartbeat {
+			return message
+		}
+	}
+}
+
+func publishFeedEvent(feed *service.CompanyFeed, position int, companyID uuid.UUID, name string) {
+	feed.Publish(strconv.Itoa(position), &event.CompanyUpdated{CompanyID: companyID, Name: name})
+}
+
+func TestCompanySocket_Subscribe(t *testing.T) {
+	t.Log("Given the need to test websocket client receives events of subscribed companies only.")
+	feed := service.NewCompanyFeed(bus.NewMemory(0), 10)
+	conn := dialCompanySocket(t, feed, time.Minute, 10)
+	google, apple, amazon := uuid.New(), uuid.New(), uuid.New()
+
+	sendSocketRequest(t, conn, socketSubscribe, google, apple)
+	message := receiveSocketMessage(t, conn)
+	require.Equal(t, socketSubscribed, message.Type)
+	require.ElementsMatch(t, []uuid.UUID{google, apple}, message.CompanyIDs)
+
+	publishFeedEvent(feed, 1, amazon, "Amazon")
+	publishFeedEvent(feed, 2, google, "Alphabet")
+	message = receiveSocketMessage(t, conn)
+	require.Equal(t, socketEvent, message.Type)
+	require.Equal(t, "2", message.ID)
+	require.Equal(t, event.UPDATE, message.Event)
+	require.Equal(t, google, *message.CompanyID)
+	require.Contains(t, string(message.Data), "Alphabet")
+
+	sendSocketRequest(t, conn, socketUnsubscribe, google)
+	message = receiveSocketMessage(t, conn)
+	require.Equal(t, socketSubscribed, message.Type)
+	require.Equal(t, []uuid.UUID{apple}, message.CompanyIDs)
+
+	publishFeedEvent(feed, 3, google, "Google")
+	publishFeedEvent(feed, 4, apple, "Apple")
+	message = receiveSocketMessage(t, conn)
+	require.Equal(t, "4", message.ID)
+	require.Equal(t, apple, *message.CompanyID)
+
+	sendSocketRequest(t, conn, "watch", amazon)
+	message = receiveSocketMessage(t, conn)
+	require.Equal(t, socketError, message.Type)
+	require.Contains(t, message.Error, "unknown action")
+	require.NoError(t, websocket.Message.Send(conn, "not json"))
+	message = receiveSocketMessage(t, conn)
+	require.Equal(t, socketError, message.Type)
+}
+
+func TestCompanySocket_MaxCompanies(t *testing.T) {
+	t.Log("Given the need to test websocket client can't watch more than limit of companies.")
+	feed := service.NewCompanyFeed(bus.NewMemory(0), 10)
+	conn := dialCompanySocket(t, feed, time.Minute, 2)
+	google, apple, amazon := uuid.New(), uuid.New(), uuid.New()
+
+	sendSocketRequest(t, conn, socketSubscribe, google, apple, amazon)
+	message := receiveSocketMessage(t, conn)
+	require.Equal(t, socketError, message.Type)
+	require.Contains(t, message.Error, "more than 2 companies")
+
+	sendSocketRequest(t, conn, socketSubscribe, google, apple)
+	message = receiveSocketMessage(t, conn)
+	require.Equal(t, socketSubscribed, message.Type)
+	sendSocketRequest(t, conn, socketSubscribe, google)
+	message = receiveSocketMessage(t, conn)
+	require.Equal(t, socketSubscribed, message.Type, "already watched company doesn't count again")
+	require.ElementsMatch(t, []uuid.UUID{google, apple}, message.CompanyIDs)
+
+	sendSocketRequest(t, conn, socketSubscribe, amazon)
+	message = receiveSocketMessage(t, conn)
+	require.Equal(t, socketError, message.Type)
+	sendSocketRequest(t, conn, socketUnsubscribe)
+	message = receiveSocketMessage(t, conn)
+	require.ElementsMatch(t, []uuid.UUID{google, apple}, message.CompanyIDs, "rejected request changes nothing")
+}
+
+func TestCompanySocket_SlowClient(t *testing.T) {
+	t.Log("Given the need to test websocket client which doesn't read events is disconnected.")
+	feed := service.NewCompanyFeed(bus.NewMemory(0), 1)
+	conn := dialCompanySocket(t, feed, 200*time.Millisecond, 10)
+	companyID := uuid.New()
+	sendSocketRequest(t, conn, socketSubscribe, companyID)
+	require.Equal(t, socketSubscribed, receiveSocketMessage(t, conn).Type)
+
+	// events are larger than socket buffers, so server blocks on writing them while client doesn't read
+	const published = 100
+	name := strings.Repeat("x", 256<<10)
+	for i := 1; i <= published; i++ {
+		publishFeedEvent(feed, i, companyID, name)
+	}
+	time.Sleep(time.Second)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	received := 0
+	for {
+		message := new(companySocketMessage)
+		err := websocket.JSON.Receive(conn, message)
+		if err != nil {
+			require.NotContains(t, err.Error(), "timeout", "server must close connection")
+			break
+		}
+		if message.Type == socketEvent {
+			received++
+		}
+	}
+	require.Less(t, received, published)
+}
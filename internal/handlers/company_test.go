@@ -21,7 +21,7 @@ func TestCompany_Create(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	defer func() {
-		_, err := dbPool.Exec(ctx, "TRUNCATE table company")
+		_, err := dbPool.Exec(ctx, "TRUNCATE table company CASCADE")
 		require.NoError(t, err)
 	}()
 	t.Log("Given the need to test create company.")
@@ -43,7 +43,7 @@ func TestCompany_GetByID(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	defer func() {
-		_, err := dbPool.Exec(ctx, "TRUNCATE table company")
+		_, err := dbPool.Exec(ctx, "TRUNCATE table company CASCADE")
 		require.NoError(t, err)
 	}()
 	t.Log("Given the need to test create company.")
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/dlq"
+	"github.com/Entetry/gocompany/internal/service"
+)
+
+// DeadLetter handler dead-lettered messages struct
+type DeadLetter struct {
+	deadLetterService *service.DeadLetter
+}
+
+// NewDeadLetter creates new dead-letter handler
+func NewDeadLetter(deadLetterService *service.DeadLetter) *DeadLetter {
+	return &DeadLetter{deadLetterService: deadLetterService}
+}
+
+// List godoc
+// @Summary Retrieves dead-lettered company messages
+// @Tags    admin
+// @Produce json
+// @Param   start query    string false "first message id"
+// @Param   count query    int    false "page size"
+// @Success 200   {array}  dlq.Message
+// @Failure 400
+// @Failure 500
+// @Router  /admin/dlq [get]
+func (d *DeadLetter) List(ctx echo.Context) error {
+	var count int64
+	if countParam := ctx.QueryParam("count"); countParam != "" {
+		var err error
+		count, err = strconv.ParseInt(countParam, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	messages, err := d.deadLetterService.List(ctx.Request().Context(), ctx.QueryParam("start"), count)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, messages)
+}
+
+// Get godoc
+// @Summary Retrieves dead-lettered company message based on given ID
+// @Tags    admin
+// @Produce json
+// @Success 200 {object} dlq.Message
+// @Failure 404
+// @Failure 500
+// @Router  /admin/dlq/{id} [get]
+func (d *DeadLetter) Get(ctx echo.Context) error {
+	message, err := d.deadLetterService.Get(ctx.Request().Context(), ctx.Param("id"))
+	if errors.Is(err, dlq.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, message)
+}
+
+// Redrive godoc
+// @Summary Puts dead-lettered company message back to company stream
+// @Tags    admin
+// @Produce json
+// @Success 200 {string} string "new message id"
+// @Failure 404
+// @Failure 500
+// @Router  /admin/dlq/{id}/redrive [post]
+func (d *DeadLetter) Redrive(ctx echo.Context) error {
+	id, err := d.deadLetterService.Redrive(ctx.Request().Context(), ctx.Param("id"))
+	if errors.Is(err, dlq.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, id)
+}
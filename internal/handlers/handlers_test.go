@@ -3,16 +3,18 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"github.com/Entetry/gocompany/internal/bus"
 	cache2 "github.com/Entetry/gocompany/internal/cache"
+	"github.com/Entetry/gocompany/internal/config"
 	"github.com/Entetry/gocompany/internal/consumer"
+	"github.com/Entetry/gocompany/internal/dlq"
 	"github.com/Entetry/gocompany/internal/event"
 	"github.com/Entetry/gocompany/internal/middleware"
 	"github.com/Entetry/gocompany/internal/producer"
 	"github.com/Entetry/gocompany/internal/repository"
 	"github.com/Entetry/gocompany/internal/service"
+	"github.com/Entetry/gocompany/internal/storage"
 	"github.com/go-playground/validator/v10"
-	"github.com/go-redis/redis/v9"
-	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/labstack/echo/v4"
 	"github.com/ory/dockertest"
@@ -70,56 +72,39 @@ func TestMain(m *testing.M) {
 		log.Fatalf("Could not connect to database: %s", err)
 	}
 
-	var redisClient *redis.Client
-	redisRsc, err := pool.Run("redis", "7-alpine", nil)
-	if err != nil {
-		log.Fatalf("Could not start resource: %s", err)
-	}
-
-	if err = pool.Retry(func() error {
-		redisClient = redis.NewClient(&redis.Options{
-			Addr: fmt.Sprintf("localhost:%s", redisRsc.GetPort("6379/tcp")),
-		})
-
-		return redisClient.Ping(ctx).Err()
-	}); err != nil {
-		log.Fatalf("Could not connect to docker: %s", err)
-	}
-
 	companyRepository := repository.NewCompanyRepository(dbPool)
 	logoRepository := repository.NewLogoRepository(dbPool)
-	cacheCompany := cache2.NewLocalCache()
-	redisProducer := producer.NewRedisCompanyProducer(redisClient)
-	companyService := service.NewCompany(companyRepository, logoRepository, cacheCompany, redisProducer)
+	cacheCompany := cache2.NewLocalCache(time.Minute, 1000)
+	eventBus := bus.NewMemory(0)
+	companyProducer := producer.NewCompanyProducer(eventBus)
+	companyService := service.NewCompany(companyRepository, logoRepository, storage.NewLocal(os.TempDir()),
+		&config.LogoConfig{MaxBytes: 1 << 20}, cacheCompany, companyProducer, false)
 	companyHandler = NewCompany(companyService)
-	go ConsumeCompanies(redisClient, cacheCompany)
+	ConsumeCompanies(ctx, eventBus, cacheCompany)
 	e = echo.New()
 	e.Validator = middleware.NewCustomValidator(validator.New())
 	code := m.Run()
-	resources := []*dockertest.Resource{pgResoursce, redisRsc}
-	for _, resource := range resources {
-		if err := pool.Purge(resource); err != nil {
-			log.Printf("Could not purge resource: %s\n", err)
-		}
-		err = resource.Expire(1)
-		if err != nil {
-			log.Print(err)
-		}
+	if err = pool.Purge(pgResoursce); err != nil {
+		log.Printf("Could not purge resource: %s\n", err)
+	}
+	err = pgResoursce.Expire(1)
+	if err != nil {
+		log.Print(err)
 	}
 
 	os.Exit(code)
 }
 
-func ConsumeCompanies(redisClient *redis.Client, localCache *cache2.LocalCache) {
-	redisCompanyConsumer := consumer.NewRedisCompanyConsumer(redisClient, fmt.Sprintf("%d000-0", time.Now().Unix()))
-	go redisCompanyConsumer.Consume(context.Background(), func(id uuid.UUID, action, name string) {
-		switch action {
-		case event.UPDATE:
-			localCache.Update(id, name)
-		case event.DELETE:
-			localCache.Delete(id)
-		default:
-			log.Error("Unknown event")
+func ConsumeCompanies(ctx context.Context, subscriber bus.Subscriber, localCache *cache2.LocalCache) {
+	companyConsumer := consumer.NewCompanyConsumer(subscriber, dlq.NewMemoryDeadLetter(), "")
+	go companyConsumer.Consume(ctx, func(_ string, e event.Event) {
+		switch e := e.(type) {
+		case *event.CompanyCreated:
+			localCache.Update(e.CompanyID, e.Name)
+		case *event.CompanyUpdated:
+			localCache.Update(e.CompanyID, e.Name)
+		case *event.CompanyDeleted:
+			localCache.Delete(e.CompanyID)
 		}
 	})
 }
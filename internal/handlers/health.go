@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Entetry/gocompany/internal/consumer"
+)
+
+// Health handler health struct
+type Health struct {
+	companyConsumer consumer.Company
+}
+
+// NewHealth creates new health handler
+func NewHealth(companyConsumer consumer.Company) *Health {
+	return &Health{companyConsumer: companyConsumer}
+}
+
+// Get godoc
+// @Summary Reports health of background company events consumer
+// @Tags    health
+// @Produce json
+// @Success 200 {object} bus.Health
+// @Failure 503 {object} bus.Health
+// @Router  /health [get]
+func (h *Health) Get(ctx echo.Context) error {
+	health := h.companyConsumer.Health()
+	if !health.Healthy() {
+		return ctx.JSON(http.StatusServiceUnavailable, health)
+	}
+	return ctx.JSON(http.StatusOK, health)
+}
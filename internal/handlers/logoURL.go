@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/service"
+)
+
+// publicLogoPath path of public logo route, followed by company id
+const publicLogoPath = "/api/public/logo/"
+
+// LogoURL handler of signed public logo urls
+type LogoURL struct {
+	companyService service.CompanyService
+	signer         *service.LogoURLSigner
+	publicURL      string
+}
+
+// NewLogoURL creates new LogoURL handler, urls are issued with publicURL base or request host if it's empty
+func NewLogoURL(companyService *service.Company, signer *service.LogoURLSigner, publicURL string) *LogoURL {
+	return &LogoURL{companyService: companyService, signer: signer, publicURL: strings.TrimSuffix(publicURL, "/")}
+}
+
+// Issue godoc
+// @Summary issue signed public url of company logo
+// @Description Url can be opened without authorization until it expires. Logo version and size are optional,
+// @Description without version url always serves current logo.
+// @Accept  json
+// @Produce json
+// @Param   input body issueLogoURLRequest false "url options"
+// @Success 200 {object} logoURLResponse
+// @Failure 400
+// @Router  /company/{id}/logo/url [post]
+func (l *LogoURL) Issue(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	request := new(issueLogoURLRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = ctx.Validate(request)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	query, expiresAt, err := l.signer.Sign(service.LogoURL{CompanyID: id, Version: request.Version,
+		Size: request.Size}, time.Duration(request.TTL)*time.Second, time.Now())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	baseURL := l.publicURL
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("%s://%s", ctx.Scheme(), ctx.Request().Host)
+	}
+	return ctx.JSON(http.StatusOK, &logoURLResponse{
+		URL:       baseURL + publicLogoPath + id.String() + "?" + query.Encode(),
+		ExpiresAt: expiresAt,
+	})
+}
+
+// Serve godoc
+// @Summary Retrieves company logo by signed public url, no authorization needed
+// @Param   version query int false "logo version"
+// @Param   size query int false "longer side of logo in pixels"
+// @Param   expires query int true "unix time url expires at"
+// @Param   kid query string true "signing key id"
+// @Param   sig query string true "url signature"
+// @Success 200
+// @Success 206
+// @Success 304
+// @Failure 403
+// @Failure 404
+// @Failure 410
+// @Router  /public/logo/{id} [get]
+func (l *LogoURL) Serve(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest)
+	}
+	now := time.Now()
+	signed, err := l.signer.Verify(id, ctx.QueryParams(), now)
+	switch {
+	case errors.Is(err, service.ErrLogoURLExpired):
+		return echo.NewHTTPError(http.StatusGone, err.Error())
+	case err != nil:
+		log.Infof("rejected public logo url of company %s: %v", id, err)
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	}
+
+	var logo *service.LogoFile
+	if signed.Version > 0 {
+		logo, err = l.companyService.GetLogoVersion(ctx.Request().Context(), id, signed.Version, signed.Size)
+	} else {
+		logo, err = l.companyService.GetLogo(ctx.Request().Context(), id, signed.Size)
+	}
+	if err != nil {
+		return logoError(err)
+	}
+	// shared caches may keep logo only while url is valid
+	maxAge := int(signed.ExpiresAt.Sub(now) / time.Second)
+	return serveLogo(ctx, logo, fmt.Sprintf("public, max-age=%d", maxAge))
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/password"
+	"github.com/Entetry/gocompany/internal/service"
+)
+
+// Me handler of signed in user account struct
+type Me struct {
+	accountService *service.Account
+}
+
+// NewMe creates new me handler
+func NewMe(accountService *service.Account) *Me {
+	return &Me{accountService: accountService}
+}
+
+// Get godoc
+// @Summary Retrieves profile of signed in user
+// @Tags    me
+// @Produce json
+// @Success 200 {object} profileResponse
+// @Failure 401
+// @Failure 404
+// @Failure 500
+// @Router  /me [get]
+func (m *Me) Get(ctx echo.Context) error {
+	userID, err := claimUserID(ctx)
+	if err != nil {
+		return err
+	}
+	user, err := m.accountService.Get(ctx.Request().Context(), userID)
+	if err != nil {
+		return accountError(err)
+	}
+	return ctx.JSON(http.StatusOK, newProfileResponse(user))
+}
+
+// Update godoc
+// @Summary     Updates username and email of signed in user
+// @Description Changing email requires current password, changed email has to be verified again
+// @Description and all sessions of user are logged out. Omitted fields are kept
+// @Tags        me
+// @Accept      json
+// @Produce     json
+// @Param       input body     updateProfileRequest true "username, email and current password"
+// @Success     200   {object} profileResponse
+// @Failure     400
+// @Failure     401
+// @Failure     403 "wrong current password"
+// @Failure     404
+// @Failure     409 "username is already taken"
+// @Failure     500
+// @Router      /me [patch]
+func (m *Me) Update(ctx echo.Context) error {
+	userID, err := claimUserID(ctx)
+	if err != nil {
+		return err
+	}
+	request := new(updateProfileRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	user, err := m.accountService.UpdateProfile(ctx.Request().Context(), userID, &service.ProfileUpdate{
+		Username:        request.Username,
+		Email:           request.Email,
+		CurrentPassword: request.CurrentPassword,
+	})
+	if err != nil {
+		return accountError(err)
+	}
+	return ctx.JSON(http.StatusOK, newProfileResponse(user))
+}
+
+// ChangePassword godoc
+// @Summary     Changes password of signed in user
+// @Description All sessions of user are logged out
+// @Tags        me
+// @Accept      json
+// @Param       input body changePasswordRequest true "current and new password"
+// @Success     204
+// @Failure     400 "new password is weak, message lists broken rules"
+// @Failure     401
+// @Failure     403 "wrong current password"
+// @Failure     404
+// @Failure     500
+// @Router      /me/password [post]
+func (m *Me) ChangePassword(ctx echo.Context) error {
+	userID, err := claimUserID(ctx)
+	if err != nil {
+		return err
+	}
+	request := new(changePasswordRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = m.accountService.ChangePassword(ctx.Request().Context(), userID, request.CurrentPassword, request.NewPassword)
+	if err != nil {
+		return accountError(err)
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// Delete godoc
+// @Summary Deletes account of signed in user
+// @Tags    me
+// @Accept  json
+// @Param   input body deleteAccountRequest true "current password"
+// @Success 204
+// @Failure 400
+// @Failure 401
+// @Failure 403 "wrong current password"
+// @Failure 404
+// @Failure 500
+// @Router  /me [delete]
+func (m *Me) Delete(ctx echo.Context) error {
+	userID, err := claimUserID(ctx)
+	if err != nil {
+		return err
+	}
+	request := new(deleteAccountRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = m.accountService.Delete(ctx.Request().Context(), userID, request.CurrentPassword)
+	if err != nil {
+		return accountError(err)
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// claimUserID returns user id of jwt set by jwt middleware
+func claimUserID(ctx echo.Context) (uuid.UUID, error) {
+	token, ok := ctx.Get("user").(*jwt.Token)
+	if !ok {
+		return uuid.Nil, echo.ErrUnauthorized
+	}
+	claim, ok := token.Claims.(*model.Claim)
+	if !ok {
+		return uuid.Nil, echo.ErrUnauthorized
+	}
+	userID, err := uuid.Parse(claim.UserID)
+	if err != nil {
+		return uuid.Nil, echo.ErrUnauthorized
+	}
+	return userID, nil
+}
+
+func accountError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrUsernameTaken):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrWrongPassword):
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	case errors.Is(err, password.ErrWeakPassword):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
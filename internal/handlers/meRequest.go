@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+type updateProfileRequest struct {
+	Username *string `json:"username" validate:"omitempty,gte=3,lte=32"`
+	Email    *string `json:"email" validate:"omitempty,email,lte=254"`
+	// CurrentPassword required to change email
+	CurrentPassword string `json:"currentPassword"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required"`
+}
+
+type deleteAccountRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+}
+
+type profileResponse struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	Status   string    `json:"status"`
+}
+
+func newProfileResponse(user *model.User) *profileResponse {
+	return &profileResponse{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Status:   user.Status,
+	}
+}
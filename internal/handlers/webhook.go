@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/event"
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/service"
+)
+
+// Webhook handler webhook subscriptions struct
+type Webhook struct {
+	webhookService *service.Webhook
+}
+
+// NewWebhook creates new webhook handler
+func NewWebhook(webhookService *service.Webhook) *Webhook {
+	return &Webhook{webhookService: webhookService}
+}
+
+// Create godoc
+// @Summary Subscribes endpoint to company events, secret is generated when empty
+// @Tags    admin
+// @Produce json
+// @Param   input body     addWebhookRequest true "subscription"
+// @Success 201   {object} webhookResponse
+// @Failure 400
+// @Failure 500
+// @Router  /admin/webhooks [post]
+func (w *Webhook) Create(ctx echo.Context) error {
+	request := new(addWebhookRequest)
+	err := ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	subscription := &model.WebhookSubscription{URL: request.URL, Events: request.Events, Secret: request.Secret}
+	err = w.webhookService.Create(ctx.Request().Context(), subscription)
+	if err != nil {
+		return webhookError(err)
+	}
+	response := newWebhookResponse(subscription)
+	response.Secret = subscription.Secret
+	return ctx.JSON(http.StatusCreated, response)
+}
+
+// GetAll godoc
+// @Summary Retrieves all webhook subscriptions
+// @Tags    admin
+// @Produce json
+// @Success 200 {array} webhookResponse
+// @Failure 500
+// @Router  /admin/webhooks [get]
+func (w *Webhook) GetAll(ctx echo.Context) error {
+	subscriptions, err := w.webhookService.GetAll(ctx.Request().Context())
+	if err != nil {
+		return webhookError(err)
+	}
+	response := make([]*webhookResponse, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		response = append(response, newWebhookResponse(subscription))
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// Get godoc
+// @Summary Retrieves webhook subscription based on given ID
+// @Tags    admin
+// @Produce json
+// @Success 200 {object} webhookResponse
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router  /admin/webhooks/{id} [get]
+func (w *Webhook) Get(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	subscription, err := w.webhookService.Get(ctx.Request().Context(), id)
+	if err != nil {
+		return webhookError(err)
+	}
+	return ctx.JSON(http.StatusOK, newWebhookResponse(subscription))
+}
+
+// Update godoc
+// @Summary Updates webhook subscription, enabling it resets failures
+// @Tags    admin
+// @Produce json
+// @Param   input body updateWebhookRequest true "subscription"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router  /admin/webhooks/{id} [put]
+func (w *Webhook) Update(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	request := new(updateWebhookRequest)
+	err = ctx.Bind(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = ctx.Validate(request)
+	if err != nil {
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	subscription := &model.WebhookSubscription{ID: id, URL: request.URL, Events: request.Events,
+		Enabled: request.Enabled}
+	err = w.webhookService.Update(ctx.Request().Context(), subscription)
+	if err != nil {
+		return webhookError(err)
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Delete godoc
+// @Summary Deletes webhook subscription with its deliveries
+// @Tags    admin
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router  /admin/webhooks/{id} [delete]
+func (w *Webhook) Delete(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = w.webhookService.Delete(ctx.Request().Context(), id)
+	if err != nil {
+		return webhookError(err)
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+// GetDeliveries godoc
+// @Summary Retrieves last deliveries of webhook subscription
+// @Tags    admin
+// @Produce json
+// @Success 200 {array} webhookDeliveryResponse
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router  /admin/webhooks/{id}/deliveries [get]
+func (w *Webhook) GetDeliveries(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	deliveries, err := w.webhookService.GetDeliveries(ctx.Request().Context(), id)
+	if err != nil {
+		return webhookError(err)
+	}
+	response := make([]*webhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		response = append(response, newWebhookDeliveryResponse(delivery))
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// GetAttempts godoc
+// @Summary Retrieves attempts of webhook delivery
+// @Tags    admin
+// @Produce json
+// @Success 200 {array} webhookAttemptResponse
+// @Failure 400
+// @Failure 500
+// @Router  /admin/webhooks/deliveries/{deliveryID}/attempts [get]
+func (w *Webhook) GetAttempts(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("deliveryID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	attempts, err := w.webhookService.GetAttempts(ctx.Request().Context(), id)
+	if err != nil {
+		return webhookError(err)
+	}
+	response := make([]*webhookAttemptResponse, 0, len(attempts))
+	for _, attempt := range attempts {
+		response = append(response, newWebhookAttemptResponse(attempt))
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func webhookError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, event.ErrUnknownType):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		log.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+type addWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+type updateWebhookRequest struct {
+	URL     string   `json:"url" validate:"required,url"`
+	Events  []string `json:"events"`
+	Enabled bool     `json:"enabled"`
+}
+
+type webhookResponse struct {
+	ID  uuid.UUID `json:"id"`
+	URL string    `json:"url"`
+	// Secret is only returned on create
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Enabled   bool      `json:"enabled"`
+	Failures  int       `json:"failures"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type webhookDeliveryResponse struct {
+	ID             uuid.UUID       `json:"id"`
+	EventID        uuid.UUID       `json:"eventID"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"nextAttemptAt"`
+	LastStatusCode int             `json:"lastStatusCode,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+}
+
+type webhookAttemptResponse struct {
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func newWebhookResponse(subscription *model.WebhookSubscription) *webhookResponse {
+	return &webhookResponse{
+		ID:        subscription.ID,
+		URL:       subscription.URL,
+		Events:    subscription.Events,
+		Enabled:   subscription.Enabled,
+		Failures:  subscription.Failures,
+		CreatedAt: subscription.CreatedAt,
+	}
+}
+
+func newWebhookDeliveryResponse(delivery *model.WebhookDelivery) *webhookDeliveryResponse {
+	return &webhookDeliveryResponse{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+func newWebhookAttemptResponse(attempt *model.WebhookAttempt) *webhookAttemptResponse {
+	return &webhookAttemptResponse{
+		Attempt:    attempt.Attempt,
+		StatusCode: attempt.StatusCode,
+		Error:      attempt.Error,
+		DurationMS: attempt.Duration.Milliseconds(),
+		CreatedAt:  attempt.CreatedAt,
+	}
+}
@@ -0,0 +1,136 @@
+// Package imaging validates uploaded images
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strings"
+
+	"golang.org/x/image/webp"
+)
+
+const (
+	// PNG content type of png images
+	PNG = "image/png"
+	// JPEG content type of jpeg images
+	JPEG = "image/jpeg"
+	// GIF content type of gif images
+	GIF = "image/gif"
+	// WebP content type of webp images
+	WebP = "image/webp"
+	// SVG content type of svg images
+	SVG = "image/svg+xml"
+)
+
+var (
+	// ErrUnsupportedFormat content isn't one of accepted image formats
+	ErrUnsupportedFormat = errors.New("unsupported image format, expected png, jpeg, gif, webp or svg")
+	// ErrInvalidImage content looks like accepted format but cannot be decoded
+	ErrInvalidImage = errors.New("invalid image")
+	// ErrTooLarge image exceeds byte or dimension limits
+	ErrTooLarge = errors.New("image is too large")
+)
+
+var extensions = map[string]string{
+	PNG:  ".png",
+	JPEG: ".jpeg",
+	GIF:  ".gif",
+	WebP: ".webp",
+	SVG:  ".svg",
+}
+
+var decoders = map[string]struct {
+	decode       func(r *bytes.Reader) (image.Image, error)
+	decodeConfig func(r *bytes.Reader) (image.Config, error)
+}{
+	PNG: {
+		decode:       func(r *bytes.Reader) (image.Image, error) { return png.Decode(r) },
+		decodeConfig: func(r *bytes.Reader) (image.Config, error) { return png.DecodeConfig(r) },
+	},
+	JPEG: {
+		decode:       func(r *bytes.Reader) (image.Image, error) { return jpeg.Decode(r) },
+		decodeConfig: func(r *bytes.Reader) (image.Config, error) { return jpeg.DecodeConfig(r) },
+	},
+	GIF: {
+		decode:       func(r *bytes.Reader) (image.Image, error) { return gif.Decode(r) },
+		decodeConfig: func(r *bytes.Reader) (image.Config, error) { return gif.DecodeConfig(r) },
+	},
+	WebP: {
+		decode:       func(r *bytes.Reader) (image.Image, error) { return webp.Decode(r) },
+		decodeConfig: func(r *bytes.Reader) (image.Config, error) { return webp.DecodeConfig(r) },
+	},
+}
+
+// Limits upload limits, zero value disables a limit
+type Limits struct {
+	MaxBytes  int64
+	MaxWidth  int
+	MaxHeight int
+}
+
+// Image validated image
+type Image struct {
+	// Data image content, sanitized for svg
+	Data        []byte
+	ContentType string
+	// Width and Height in pixels, zero for svg without explicit size
+	Width  int
+	Height int
+}
+
+// Extension returns file extension of content type
+func Extension(contentType string) string {
+	return extensions[contentType]
+}
+
+// Validate detects format of data by its content, checks limits and decodes it to reject corrupt images.
+// Dimensions are checked before pixels are decoded so decompression bombs are never allocated.
+func Validate(data []byte, limits Limits) (*Image, error) {
+	if limits.MaxBytes > 0 && int64(len(data)) > limits.MaxBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, limits.MaxBytes)
+	}
+	contentType := http.DetectContentType(data)
+	if decoder, ok := decoders[contentType]; ok {
+		config, err := decoder.decodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		err = checkDimensions(config.Width, config.Height, limits)
+		if err != nil {
+			return nil, err
+		}
+		_, err = decoder.decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		return &Image{Data: data, ContentType: contentType, Width: config.Width, Height: config.Height}, nil
+	}
+	if strings.HasPrefix(contentType, "text/") {
+		svg, err := sanitizeSVG(data)
+		if err != nil {
+			return nil, err
+		}
+		err = checkDimensions(svg.Width, svg.Height, limits)
+		if err != nil {
+			return nil, err
+		}
+		return svg, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func checkDimensions(width, height int, limits Limits) error {
+	if width < 0 || height < 0 {
+		return fmt.Errorf("%w: negative dimensions", ErrInvalidImage)
+	}
+	if limits.MaxWidth > 0 && width > limits.MaxWidth || limits.MaxHeight > 0 && height > limits.MaxHeight {
+		return fmt.Errorf("%w: %dx%d exceeds %dx%d", ErrTooLarge, width, height, limits.MaxWidth, limits.MaxHeight)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var limits = Limits{MaxBytes: 1 << 20, MaxWidth: 64, MaxHeight: 64}
+
+func encode(t *testing.T, contentType string, width, height int) []byte {
+	img := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.White, color.Black})
+	var buf bytes.Buffer
+	var err error
+	switch contentType {
+	case PNG:
+		err = png.Encode(&buf, img)
+	case JPEG:
+		err = jpeg.Encode(&buf, img, nil)
+	case GIF:
+		err = gif.Encode(&buf, img, nil)
+	}
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestValidate_Raster(t *testing.T) {
+	t.Log("Given the need to test raster images are detected by content and limited.")
+	for _, contentType := range []string{PNG, JPEG, GIF} {
+		img, err := Validate(encode(t, contentType, 32, 16), limits)
+		require.NoError(t, err)
+		require.Equal(t, contentType, img.ContentType)
+		require.Equal(t, 32, img.Width)
+		require.Equal(t, 16, img.Height)
+
+		_, err = Validate(encode(t, contentType, 65, 16), limits)
+		require.ErrorIs(t, err, ErrTooLarge)
+
+		data := encode(t, contentType, 32, 32)
+		_, err = Validate(data[:len(data)/2], limits)
+		require.ErrorIs(t, err, ErrInvalidImage)
+	}
+
+	_, err := Validate(encode(t, PNG, 32, 32), Limits{MaxBytes: 10})
+	require.ErrorIs(t, err, ErrTooLarge)
+	_, err = Validate([]byte("%PDF-1.4 not an image"), limits)
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+	_, err = Validate([]byte("just text"), limits)
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestValidate_SVG(t *testing.T) {
+	t.Log("Given the need to test svg is sanitized.")
+	svg := `<?xml version="1.0"?>
+<!DOCTYPE svg>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 48 24" onload="alert(1)">
+	<!-- comment -->
+	<script>alert(1)</script>
+	<foreignObject><div>html</div></foreignObject>
+	<style>@import url(https://evil.example/x.css);</style>
+	<defs><linearGradient id="g"/></defs>
+	<rect width="10" height="10" fill="url(#g)" style="fill:url(https://evil.example/p)"/>
+	<a xlink:href="javascript:alert(1)"><text>R&amp;D</text></a>
+	<image href="data:image/png;base64,AAAA"/>
+</svg>`
+	img, err := Validate([]byte(svg), limits)
+	require.NoError(t, err)
+	require.Equal(t, SVG, img.ContentType)
+	require.Equal(t, 48, img.Width)
+	require.Equal(t, 24, img.Height)
+
+	sanitized := string(img.Data)
+	for _, removed := range []string{"onload", "script", "alert", "foreignObject", "html", "evil", "comment",
+		"DOCTYPE"} {
+		require.NotContains(t, sanitized, removed)
+	}
+	for _, kept := range []string{`fill="url(#g)"`, "R&amp;D", "data:image/png;base64,AAAA", "<svg"} {
+		require.Contains(t, sanitized, kept)
+	}
+	_, err = Validate(img.Data, limits)
+	require.NoError(t, err)
+
+	_, err = Validate([]byte(`<svg width="100000" height="10"></svg>`), limits)
+	require.ErrorIs(t, err, ErrTooLarge)
+	_, err = Validate([]byte(`<html><body></body></html>`), limits)
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+	_, err = Validate([]byte(`<svg><rect></svg>`+strings.Repeat(" ", 10)), limits)
+	require.ErrorIs(t, err, ErrInvalidImage)
+}
+
+func TestResize(t *testing.T) {
+	t.Log("Given the need to test images are scaled down keeping aspect ratio.")
+	src := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for x := 0; x < 100; x++ {
+		for y := 0; y < 100; y++ {
+			src.Set(x, y, color.White)
+		}
+	}
+	resized := Resize(src, 50)
+	require.Equal(t, image.Rect(0, 0, 50, 25), resized.Bounds())
+	require.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, resized.At(10, 10))
+	require.Equal(t, color.RGBA{}, resized.At(40, 10))
+
+	require.Same(t, src, Resize(src, 512))
+
+	data, err := Encode(resized, RenditionType(GIF))
+	require.NoError(t, err)
+	img, err := Validate(data, limits)
+	require.NoError(t, err)
+	require.Equal(t, PNG, img.ContentType)
+}
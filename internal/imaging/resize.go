@@ -0,0 +1,100 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+const renditionJPEGQuality = 90
+
+// Decode decodes validated raster image
+func Decode(img *Image) (image.Image, error) {
+	decoder, ok := decoders[img.ContentType]
+	if !ok {
+		return nil, ErrUnsupportedFormat
+	}
+	return decoder.decode(bytes.NewReader(img.Data))
+}
+
+// Resizable reports whether content type is raster image which can be resized
+func Resizable(contentType string) bool {
+	_, ok := decoders[contentType]
+	return ok
+}
+
+// RenditionType content type of resized image, jpeg stays jpeg and other formats become png
+func RenditionType(contentType string) string {
+	if contentType == JPEG {
+		return JPEG
+	}
+	return PNG
+}
+
+// Resize scales image down so its longer side is maxSide, averaging covered source pixels.
+// Images which already fit are returned as is.
+func Resize(src image.Image, maxSide int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSide && height <= maxSide {
+		return src
+	}
+	dstWidth, dstHeight := maxSide, maxSide
+	if width > height {
+		dstHeight = maxInt(1, height*maxSide/width)
+	} else {
+		dstWidth = maxInt(1, width*maxSide/height)
+	}
+
+	// premultiplied alpha keeps transparent pixels from bleeding their color into edges
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0, y1 := y*height/dstHeight, maxInt((y+1)*height/dstHeight, y*height/dstHeight+1)
+		for x := 0; x < dstWidth; x++ {
+			x0, x1 := x*width/dstWidth, maxInt((x+1)*width/dstWidth, x*width/dstWidth+1)
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride+x0*4 : sy*rgba.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += int(row[i])
+					g += int(row[i+1])
+					b += int(row[i+2])
+					a += int(row[i+3])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// Encode encodes image as contentType returned by RenditionType
+func Encode(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == JPEG {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: renditionJPEGQuality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
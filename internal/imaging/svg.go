@@ -0,0 +1,197 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// forbiddenSVGElements are dropped together with their content
+var forbiddenSVGElements = map[string]bool{
+	"script":        true,
+	"foreignobject": true,
+	"iframe":        true,
+	"embed":         true,
+	"object":        true,
+	"audio":         true,
+	"video":         true,
+	"handler":       true,
+	"listener":      true,
+}
+
+// sanitizeSVG re-serializes svg without scripts, event handlers, comments, doctype and external references
+func sanitizeSVG(data []byte) (*Image, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+	var out bytes.Buffer
+	img := &Image{ContentType: SVG}
+	// skip depth inside forbidden element, root is false once svg element is seen
+	skip := 0
+	root := true
+	inStyle := false
+	// RawToken keeps namespace prefixes but doesn't match end elements on its own
+	var open []xml.Name
+	for {
+		token, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if root {
+				return nil, ErrUnsupportedFormat
+			}
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			open = append(open, t.Name)
+			if skip > 0 {
+				skip++
+				continue
+			}
+			local := strings.ToLower(t.Name.Local)
+			if root {
+				if local != "svg" {
+					return nil, ErrUnsupportedFormat
+				}
+				root = false
+				img.Width, img.Height = svgSize(t.Attr)
+			}
+			if forbiddenSVGElements[local] {
+				skip = 1
+				continue
+			}
+			inStyle = local == "style"
+			writeStartElement(&out, t)
+		case xml.EndElement:
+			if len(open) == 0 || open[len(open)-1] != t.Name {
+				return nil, fmt.Errorf("%w: unexpected end element %s", ErrInvalidImage, xmlName(t.Name))
+			}
+			open = open[:len(open)-1]
+			if skip > 0 {
+				skip--
+				continue
+			}
+			inStyle = false
+			out.WriteString("</" + xmlName(t.Name) + ">")
+		case xml.CharData:
+			if skip > 0 || inStyle && unsafeCSS(string(t)) {
+				continue
+			}
+			_ = xml.EscapeText(&out, t)
+		}
+	}
+	if root {
+		return nil, ErrUnsupportedFormat
+	}
+	if len(open) > 0 {
+		return nil, fmt.Errorf("%w: unclosed element %s", ErrInvalidImage, xmlName(open[len(open)-1]))
+	}
+	img.Data = out.Bytes()
+	return img, nil
+}
+
+func writeStartElement(out *bytes.Buffer, element xml.StartElement) {
+	out.WriteString("<" + xmlName(element.Name))
+	for _, attr := range element.Attr {
+		if !safeSVGAttr(attr) {
+			continue
+		}
+		out.WriteString(" " + xmlName(attr.Name) + `="`)
+		_ = xml.EscapeText(out, []byte(attr.Value))
+		out.WriteString(`"`)
+	}
+	out.WriteString(">")
+}
+
+func safeSVGAttr(attr xml.Attr) bool {
+	name := strings.ToLower(attr.Name.Local)
+	if strings.HasPrefix(name, "on") {
+		return false
+	}
+	if name == "href" {
+		return safeReference(strings.TrimSpace(attr.Value))
+	}
+	return !unsafeCSS(attr.Value)
+}
+
+// safeReference allows references to the same document and embedded raster images only
+func safeReference(ref string) bool {
+	ref = strings.ToLower(ref)
+	if strings.HasPrefix(ref, "#") {
+		return true
+	}
+	for _, contentType := range []string{PNG, JPEG, GIF, WebP} {
+		if strings.HasPrefix(ref, "data:"+contentType+";") || strings.HasPrefix(ref, "data:"+contentType+",") {
+			return true
+		}
+	}
+	return false
+}
+
+// unsafeCSS reports whether style can run script or load external resources
+func unsafeCSS(style string) bool {
+	style = strings.ToLower(strings.Join(strings.Fields(style), ""))
+	if strings.Contains(style, "javascript:") || strings.Contains(style, "expression(") ||
+		strings.Contains(style, "@import") {
+		return true
+	}
+	for rest := style; ; {
+		i := strings.Index(rest, "url(")
+		if i < 0 {
+			return false
+		}
+		rest = strings.TrimLeft(rest[i+len("url("):], `"'`)
+		if !safeReference(rest) {
+			return true
+		}
+	}
+}
+
+// maxSVGLength caps parsed lengths so absurd values don't overflow int
+const maxSVGLength = 1 << 30
+
+// svgSize returns size from width and height attributes or from viewBox, zero when unknown
+func svgSize(attrs []xml.Attr) (width, height int) {
+	var viewBox string
+	for _, attr := range attrs {
+		switch attr.Name.Local {
+		case "width":
+			width = svgLength(attr.Value)
+		case "height":
+			height = svgLength(attr.Value)
+		case "viewBox":
+			viewBox = attr.Value
+		}
+	}
+	if width > 0 && height > 0 {
+		return width, height
+	}
+	fields := strings.FieldsFunc(viewBox, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) == 4 {
+		return svgLength(fields[2]), svgLength(fields[3])
+	}
+	return width, height
+}
+
+func svgLength(value string) int {
+	length, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "px"), 64)
+	if err != nil || length < 0 {
+		return 0
+	}
+	if length > maxSVGLength {
+		return maxSVGLength
+	}
+	return int(length)
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
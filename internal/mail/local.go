@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Log writes emails to log instead of sending them, for local development
+type Log struct {
+	from string
+}
+
+// NewLog creates Log sender
+func NewLog(from string) *Log {
+	return &Log{from: from}
+}
+
+// Send logs message
+func (l *Log) Send(_ context.Context, message *Message) error {
+	email, err := build(l.from, message, time.Now())
+	if err != nil {
+		return err
+	}
+	log.Infof("mail to %s:\n%s", message.To, email)
+	return nil
+}
+
+// File writes every email to .eml file in directory instead of sending it, for local testing
+type File struct {
+	dir  string
+	from string
+}
+
+// NewFile creates File sender
+func NewFile(dir, from string) *File {
+	return &File{dir: dir, from: from}
+}
+
+// Send writes message to new file
+func (f *File) Send(_ context.Context, message *Message) error {
+	email, err := build(f.from, message, time.Now())
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(f.dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), uuid.NewString())
+	return os.WriteFile(filepath.Join(f.dir, name), email, 0o600)
+}
@@ -0,0 +1,32 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_Send(t *testing.T) {
+	t.Log("Given the need to test file sender writes email with headers and CRLF line endings.")
+	dir := t.TempDir()
+	sender := NewFile(dir, "noreply@example.com")
+
+	err := sender.Send(context.Background(), &Message{To: "user@example.com", Subject: "Verify your email",
+		Body: "Hello\nclick the link"})
+	require.NoError(t, err)
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	email, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(email), "From: noreply@example.com\r\nTo: user@example.com\r\n"))
+	require.Contains(t, string(email), "\r\n\r\nHello\r\nclick the link")
+
+	err = sender.Send(context.Background(), &Message{To: "user@example.com\r\nBcc: other@example.com",
+		Subject: "Verify", Body: "x"})
+	require.ErrorIs(t, err, ErrInvalidMessage)
+}
@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTP sends emails through SMTP server, STARTTLS is used when server supports it
+type SMTP struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTP creates SMTP sender, authenticates with PLAIN auth when username is set
+func NewSMTP(host string, port int, username, password, from string) *SMTP {
+	sender := &SMTP{addr: net.JoinHostPort(host, strconv.Itoa(port)), from: from}
+	if username != "" {
+		sender.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return sender
+}
+
+// Send sends message, net/smtp has no context support so ctx is only checked before sending
+func (s *SMTP) Send(ctx context.Context, message *Message) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	email, err := build(s.from, message, time.Now())
+	if err != nil {
+		return err
+	}
+	err = smtp.SendMail(s.addr, s.auth, s.from, []string{message.To}, email)
+	if err != nil {
+		return fmt.Errorf("cannot send mail: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+// NewAdminMiddleware creates middleware which allows only listed users, must be used after jwt middleware
+func NewAdminMiddleware(adminUserIDs []string) echo.MiddlewareFunc {
+	admins := make(map[string]struct{}, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = struct{}{}
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			token, ok := ctx.Get("user").(*jwt.Token)
+			if !ok {
+				return echo.ErrUnauthorized
+			}
+			claim, ok := token.Claims.(*model.Claim)
+			if !ok {
+				return echo.ErrUnauthorized
+			}
+			if _, ok = admins[claim.UserID]; !ok {
+				return echo.NewHTTPError(http.StatusForbidden)
+			}
+			return next(ctx)
+		}
+	}
+}
@@ -14,3 +14,13 @@ func NewJwtMiddleware(accessTokenKey string) echo.MiddlewareFunc {
 		Claims:     new(model.Claim),
 	})
 }
+
+// NewWebSocketJwtMiddleware creates jwt middleware which also takes token from "token" query parameter,
+// browsers cannot set headers of websocket handshake
+func NewWebSocketJwtMiddleware(accessTokenKey string) echo.MiddlewareFunc {
+	return middleware.JWTWithConfig(middleware.JWTConfig{
+		SigningKey:  []byte(accessTokenKey),
+		Claims:      new(model.Claim),
+		TokenLookup: "header:" + echo.HeaderAuthorization + ",query:token",
+	})
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment document attached to company, uploaded in chunks
+type Attachment struct {
+	ID          uuid.UUID
+	CompanyID   uuid.UUID
+	FileName    string
+	ContentType string
+	// Size declared size of whole file
+	Size int64
+	// Offset count of bytes uploaded so far, upload is complete when it reaches Size
+	Offset int64
+	// Chunks count of uploaded chunks
+	Chunks int
+	// HashState marshaled sha256 state of uploaded bytes, so hash is continued by next chunk
+	HashState []byte
+	// Checksum hex sha256 of whole file, set when upload is complete
+	Checksum    string
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+// Complete reports whether all bytes were uploaded
+func (a *Attachment) Complete() bool {
+	return a.Offset == a.Size
+}
+
+// AttachmentChunk uploaded part of attachment kept as separate blob
+type AttachmentChunk struct {
+	AttachmentID uuid.UUID
+	Index        int
+	Offset       int64
+	Size         int64
+	StorageKey   string
+}
@@ -1,10 +1,29 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"time"
 
-// Logo company logo domain model
+	"github.com/google/uuid"
+)
+
+// Logo company logo domain model, every uploaded logo is kept as a version of company logo
 type Logo struct {
 	ID        uuid.UUID
 	CompanyID uuid.UUID
-	Image     string
+	// StorageKey key of logo blob in blob store
+	StorageKey  string
+	ContentType string
+	// Version number of logo among company logos, starting from 1
+	Version int
+	// Current logo is the one served as company logo, company has at most one
+	Current   bool
+	CreatedAt time.Time
+}
+
+// LogoBlob stored logo content shared by identical logo versions
+type LogoBlob struct {
+	StorageKey  string
+	ContentType string
+	// RefCount number of logo versions referencing blob
+	RefCount int
 }
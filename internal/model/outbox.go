@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEvent company event waiting to be published
+type OutboxEvent struct {
+	ID            int64
+	CompanyID     uuid.UUID
+	Event         string
+	Version       int
+	Payload       []byte
+	Attempts      int
+	NextAttemptAt time.Time
+}
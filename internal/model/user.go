@@ -1,6 +1,24 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// UserPending user signed up but hasn't verified email yet
+	UserPending = "pending"
+	// UserActive user with verified email
+	UserActive = "active"
+)
+
+const (
+	// TokenEmailVerification purpose of token verifying user email
+	TokenEmailVerification = "email_verification"
+	// TokenPasswordReset purpose of token resetting forgotten password
+	TokenPasswordReset = "password_reset"
+)
 
 // User user domain model
 type User struct {
@@ -8,4 +26,14 @@ type User struct {
 	Username     string
 	Email        string
 	PasswordHash string
+	Status       string
+}
+
+// UserToken single-use token sent to user, only hash of token is kept
+type UserToken struct {
+	Hash      string
+	UserID    uuid.UUID
+	Purpose   string
+	ExpiresAt time.Time
+	CreatedAt time.Time
 }
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// WebhookPending delivery waits for its next attempt
+	WebhookPending = "pending"
+	// WebhookDelivered delivery was accepted by endpoint
+	WebhookDelivered = "delivered"
+	// WebhookFailed delivery ran out of attempts
+	WebhookFailed = "failed"
+)
+
+// WebhookSubscription partner endpoint notified about company events
+type WebhookSubscription struct {
+	ID     uuid.UUID
+	URL    string
+	Secret string
+	// Events types of delivered events, all events when empty
+	Events    []string
+	Enabled   bool
+	Failures  int
+	CreatedAt time.Time
+}
+
+// WebhookDelivery company event to be delivered to subscription
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	EventID        uuid.UUID
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	// URL and Secret of subscription, filled for claimed deliveries
+	URL    string
+	Secret string
+}
+
+// WebhookAttempt single try to deliver webhook
+type WebhookAttempt struct {
+	ID         int64
+	DeliveryID uuid.UUID
+	Attempt    int
+	StatusCode int
+	Error      string
+	Duration   time.Duration
+	CreatedAt  time.Time
+}
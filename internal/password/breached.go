@@ -0,0 +1,63 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1" //nolint:gosec // sha1 is the format of breached password lists, not used for security
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// breachedPrefixLength length of hash prefix naming range file, same as range API of Have I Been Pwned
+const breachedPrefixLength = 5
+
+// BreachedList offline list of breached passwords split into range files by k-anonymity scheme:
+// file named by first 5 hex digits of uppercase SHA-1 of password lists remaining 35 digits,
+// one "SUFFIX:COUNT" per line, so only one small file is read per check
+type BreachedList struct {
+	dir string
+}
+
+// NewBreachedList creates list of range files in dir
+func NewBreachedList(dir string) (*BreachedList, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open breached password list: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("breached password list %s is not a directory", dir)
+	}
+	return &BreachedList{dir: dir}, nil
+}
+
+// Contains reports whether password is in list
+func (b *BreachedList) Contains(password string) (bool, error) {
+	hash := sha1.Sum([]byte(password)) //nolint:gosec
+	hexHash := strings.ToUpper(hex.EncodeToString(hash[:]))
+	prefix, suffix := hexHash[:breachedPrefixLength], hexHash[breachedPrefixLength:]
+
+	file, err := os.Open(filepath.Join(b.dir, prefix))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot read breached password list: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineSuffix, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(lineSuffix, suffix) {
+			return true, nil
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return false, fmt.Errorf("cannot read breached password list: %v", err)
+	}
+	return false, nil
+}
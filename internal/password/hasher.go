@@ -0,0 +1,76 @@
+// Package password hashes and verifies user passwords, every hash carries its algorithm and parameters
+// so hashes made with older settings keep working and can be upgraded
+package password
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrUnknownHash hash isn't made by any supported algorithm
+var ErrUnknownHash = errors.New("unknown password hash")
+
+// Algorithm password hashing algorithm
+type Algorithm interface {
+	// Hash returns encoded hash of password with random salt
+	Hash(password string) (string, error)
+	// Verify reports whether password matches hash made by this algorithm
+	Verify(hash, password string) (bool, error)
+	// Recognizes reports whether hash is made by this algorithm
+	Recognizes(hash string) bool
+	// Outdated reports whether hash is made with other parameters than configured
+	Outdated(hash string) bool
+}
+
+// Hasher hashes passwords with preferred algorithm and verifies hashes of all known algorithms
+type Hasher struct {
+	preferred  Algorithm
+	algorithms []Algorithm
+	// dummyHash hash of preferred algorithm verified when there is no real one, made on first use
+	dummyOnce sync.Once
+	dummyHash string
+}
+
+// NewHasher creates hasher hashing with preferred algorithm, others are only used for verification
+func NewHasher(preferred Algorithm, others ...Algorithm) *Hasher {
+	return &Hasher{preferred: preferred, algorithms: append([]Algorithm{preferred}, others...)}
+}
+
+// Hash hashes password with preferred algorithm
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+// Verify reports whether password matches hash and whether hash should be replaced
+// with new hash of preferred algorithm
+func (h *Hasher) Verify(hash, password string) (ok, rehash bool, err error) {
+	for _, algorithm := range h.algorithms {
+		if !algorithm.Recognizes(hash) {
+			continue
+		}
+		ok, err = algorithm.Verify(hash, password)
+		if err != nil {
+			return false, false, fmt.Errorf("cannot verify password: %w", err)
+		}
+		return ok, ok && (algorithm != h.preferred || algorithm.Outdated(hash)), nil
+	}
+	return false, false, ErrUnknownHash
+}
+
+// VerifyDummy verifies password against a fixed hash of preferred algorithm, so checking password of user
+// which doesn't exist takes as long as checking a real one and doesn't tell the user doesn't exist
+func (h *Hasher) VerifyDummy(password string) {
+	h.dummyOnce.Do(func() {
+		var err error
+		h.dummyHash, err = h.preferred.Hash("dummy-password")
+		if err != nil {
+			log.Errorf("cannot make dummy password hash: %v", err)
+		}
+	})
+	if h.dummyHash != "" {
+		_, _ = h.preferred.Verify(h.dummyHash, password)
+	}
+}
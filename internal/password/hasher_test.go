@@ -0,0 +1,79 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHasher(t *testing.T) {
+	t.Log("Given the need to test hashes of preferred algorithm are verified and others are marked for rehash.")
+	argon2id := &Argon2id{Time: 1, Memory: 64, Threads: 1}
+	legacy := &Bcrypt{Cost: bcrypt.MinCost}
+	hasher := NewHasher(argon2id, legacy)
+
+	hash, err := hasher.Hash("secret-password")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"))
+	other, err := hasher.Hash("secret-password")
+	require.NoError(t, err)
+	require.NotEqual(t, hash, other)
+
+	ok, rehash, err := hasher.Verify(hash, "secret-password")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.False(t, rehash)
+	ok, _, err = hasher.Verify(hash, "wrong-password")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	legacyHash, err := legacy.Hash("secret-password")
+	require.NoError(t, err)
+	ok, rehash, err = hasher.Verify(legacyHash, "secret-password")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, rehash)
+	ok, rehash, err = hasher.Verify(legacyHash, "wrong-password")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.False(t, rehash)
+
+	_, rehash, err = NewHasher(&Argon2id{Time: 2, Memory: 64, Threads: 1}).Verify(hash, "secret-password")
+	require.NoError(t, err)
+	require.True(t, rehash)
+
+	_, _, err = hasher.Verify("plain-text", "plain-text")
+	require.ErrorIs(t, err, ErrUnknownHash)
+	_, _, err = hasher.Verify("$argon2id$v=19$m=64,t=1,p=1$broken", "secret-password")
+	require.Error(t, err)
+}
+
+// countingAlgorithm counts hashes and verifications of wrapped algorithm
+type countingAlgorithm struct {
+	*Argon2id
+	hashed   int
+	verified int
+}
+
+func (c *countingAlgorithm) Hash(password string) (string, error) {
+	c.hashed++
+	return c.Argon2id.Hash(password)
+}
+
+func (c *countingAlgorithm) Verify(hash, password string) (bool, error) {
+	c.verified++
+	return c.Argon2id.Verify(hash, password)
+}
+
+func TestHasher_VerifyDummy(t *testing.T) {
+	t.Log("Given the need to test password of missing user is verified as long as a real one.")
+	preferred := &countingAlgorithm{Argon2id: &Argon2id{Time: 1, Memory: 64, Threads: 1}}
+	hasher := NewHasher(preferred, &Bcrypt{Cost: bcrypt.MinCost})
+
+	hasher.VerifyDummy("secret-password")
+	hasher.VerifyDummy("other-password")
+	require.Equal(t, 1, preferred.hashed, "dummy hash is made once")
+	require.Equal(t, 2, preferred.verified)
+}
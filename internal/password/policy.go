@@ -0,0 +1,101 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minUserInfoLength shorter usernames and email names are too likely to appear by chance
+const minUserInfoLength = 3
+
+// ErrWeakPassword password doesn't satisfy policy
+var ErrWeakPassword = errors.New("weak password")
+
+// PolicyError lists every rule password breaks so user can fix all of them at once
+type PolicyError struct {
+	Reasons []string
+}
+
+func (e *PolicyError) Error() string {
+	return "password " + strings.Join(e.Reasons, ", ")
+}
+
+// Unwrap makes errors.Is(err, ErrWeakPassword) true
+func (e *PolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+// Policy rules of new passwords, zero values disable rules
+type Policy struct {
+	MinLength int
+	MaxLength int
+	// MinClasses number of classes out of lowercase, uppercase, digits and other characters
+	MinClasses int
+	// RejectUserInfo rejects passwords containing username or name part of email
+	RejectUserInfo bool
+	// Breached rejects passwords found in breached password list, nil disables the check
+	Breached *BreachedList
+}
+
+// Check returns PolicyError if password of user breaks policy
+func (p *Policy) Check(password, username, email string) error {
+	var reasons []string
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		reasons = append(reasons, fmt.Sprintf("must be at least %d characters long", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		reasons = append(reasons, fmt.Sprintf("must be at most %d characters long", p.MaxLength))
+	}
+	if classes := countClasses(password); classes < p.MinClasses {
+		reasons = append(reasons, fmt.Sprintf("must contain at least %d of lowercase letters, uppercase letters, "+
+			"digits and other characters", p.MinClasses))
+	}
+	if p.RejectUserInfo && containsUserInfo(password, username, email) {
+		reasons = append(reasons, "must not contain username or email")
+	}
+	if len(reasons) == 0 && p.Breached != nil {
+		breached, err := p.Breached.Contains(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			reasons = append(reasons, "was found in a data breach, choose another one")
+		}
+	}
+	if len(reasons) > 0 {
+		return &PolicyError{Reasons: reasons}
+	}
+	return nil
+}
+
+func countClasses(password string) int {
+	var lower, upper, digit, other int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return lower + upper + digit + other
+}
+
+func containsUserInfo(password, username, email string) bool {
+	password = strings.ToLower(password)
+	name, _, _ := strings.Cut(email, "@")
+	for _, info := range []string{username, name} {
+		if utf8.RuneCountInString(info) >= minUserInfoLength && strings.Contains(password, strings.ToLower(info)) {
+			return true
+		}
+	}
+	return false
+}
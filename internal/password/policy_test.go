@@ -0,0 +1,56 @@
+package password
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	t.Log("Given the need to test policy lists every broken rule.")
+	policy := &Policy{MinLength: 8, MaxLength: 16, MinClasses: 3, RejectUserInfo: true}
+
+	require.NoError(t, policy.Check("Correct-horse1", "alice", "alice@example.com"))
+
+	err := policy.Check("alice", "alice", "a@example.com")
+	require.ErrorIs(t, err, ErrWeakPassword)
+	var policyErr *PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	require.Len(t, policyErr.Reasons, 3)
+	require.Contains(t, err.Error(), "at least 8 characters")
+	require.Contains(t, err.Error(), "username or email")
+
+	err = policy.Check("Xx1-bob.smith-Xx", "robert", "Bob.Smith@example.com")
+	require.ErrorIs(t, err, ErrWeakPassword)
+	require.NoError(t, policy.Check("Xx1-bob-Xx", "al", "al@example.com"))
+	require.ErrorContains(t, policy.Check("Very-long-password-1", "", ""), "at most 16 characters")
+}
+
+func TestBreachedList(t *testing.T) {
+	t.Log("Given the need to test breached passwords are found in range file of their hash prefix.")
+	dir := t.TempDir()
+	hash := sha1.Sum([]byte("P@ssw0rd")) //nolint:gosec
+	hexHash := strings.ToUpper(hex.EncodeToString(hash[:]))
+	content := "0000000000000000000000000000000000A:1\r\n" + strings.ToLower(hexHash[5:]) + ":12345\r\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, hexHash[:5]), []byte(content), 0o600))
+
+	breached, err := NewBreachedList(dir)
+	require.NoError(t, err)
+	found, err := breached.Contains("P@ssw0rd")
+	require.NoError(t, err)
+	require.True(t, found)
+	found, err = breached.Contains("Correct-horse1")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	policy := &Policy{MinLength: 8, MinClasses: 3, Breached: breached}
+	require.ErrorContains(t, policy.Check("P@ssw0rd", "alice", "alice@example.com"), "data breach")
+
+	_, err = NewBreachedList(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
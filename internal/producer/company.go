@@ -4,35 +4,32 @@ package producer
 import (
 	"context"
 
-	"github.com/go-redis/redis/v9"
-	"github.com/google/uuid"
+	"github.com/Entetry/gocompany/internal/bus"
+	"github.com/Entetry/gocompany/internal/event"
 )
 
 // Company producer company interface
 type Company interface {
-	Produce(ctx context.Context, id uuid.UUID, event, name string) error
+	Produce(ctx context.Context, e event.Event) error
 }
 
-type redisCompany struct {
-	redis *redis.Client
+type company struct {
+	publisher bus.Publisher
 }
 
-// NewRedisCompanyProducer creates new producer to price stream
-func NewRedisCompanyProducer(redisClient *redis.Client) Company {
-	return &redisCompany{
-		redis: redisClient,
+// NewCompanyProducer creates new producer to company topic
+func NewCompanyProducer(publisher bus.Publisher) Company {
+	return &company{
+		publisher: publisher,
 	}
 }
 
-// Produce Push new company record into redis stream
-func (r *redisCompany) Produce(ctx context.Context, id uuid.UUID, event, name string) error {
-	args := &redis.XAddArgs{
-		Stream: "company",
-		Values: map[string]interface{}{
-			"id":    id.String(),
-			"event": event,
-			"name":  name,
-		},
+// Produce Push new company event into company topic
+func (c *company) Produce(ctx context.Context, e event.Event) error {
+	envelope, err := event.NewEnvelope(e)
+	if err != nil {
+		return err
 	}
-	return r.redis.XAdd(ctx, args).Err()
+	_, err = c.publisher.Publish(ctx, event.CompanyTopic, envelope)
+	return err
 }
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+const attachmentColumns = `id, company_id, file_name, content_type, size, "offset", chunks, hash_state, checksum,
+	created_at, completed_at`
+
+// AttachmentRepository company attachment repository interface
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *model.Attachment) (bool, error)
+	Get(ctx context.Context, companyID, id uuid.UUID) (*model.Attachment, error)
+	GetByCompanyID(ctx context.Context, companyID uuid.UUID) ([]*model.Attachment, error)
+	AppendChunk(ctx context.Context, attachment *model.Attachment, chunk *model.AttachmentChunk) (bool, error)
+	GetChunks(ctx context.Context, attachmentID uuid.UUID) ([]*model.AttachmentChunk, error)
+	GetChunkKeys(ctx context.Context) ([]string, error)
+	DeleteIncomplete(ctx context.Context, createdBefore time.Time) (int64, error)
+}
+
+// Attachment company attachment postgres repository struct
+type Attachment struct {
+	db *pgxpool.Pool
+}
+
+// NewAttachmentRepository creates new Attachment repository
+func NewAttachmentRepository(db *pgxpool.Pool) *Attachment {
+	return &Attachment{db: db}
+}
+
+// Create inserts attachment with nothing uploaded yet, returns false if its company doesn't exist
+func (a *Attachment) Create(ctx context.Context, attachment *model.Attachment) (bool, error) {
+	err := a.db.QueryRow(ctx, `INSERT INTO attachment (id, company_id, file_name, content_type, size, hash_state)
+		SELECT $1, id, $3, $4, $5, $6 FROM company WHERE id = $2 RETURNING created_at`, attachment.ID,
+		attachment.CompanyID, attachment.FileName, attachment.ContentType, attachment.Size, attachment.HashState).
+		Scan(&attachment.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot create Attachment: %v", err)
+	}
+	return true, nil
+}
+
+// Get gets company attachment, nil if there is no such attachment
+func (a *Attachment) Get(ctx context.Context, companyID, id uuid.UUID) (*model.Attachment, error) {
+	attachment, err := scanAttachment(a.db.QueryRow(ctx, `SELECT `+attachmentColumns+` FROM attachment
+		WHERE id = $1 AND company_id = $2`, id, companyID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get Attachment: %v", err)
+	}
+	return attachment, nil
+}
+
+// GetByCompanyID gets all attachments of company, latest first
+func (a *Attachment) GetByCompanyID(ctx context.Context, companyID uuid.UUID) ([]*model.Attachment, error) {
+	rows, err := a.db.Query(ctx, `SELECT `+attachmentColumns+` FROM attachment WHERE company_id = $1
+		ORDER BY created_at DESC`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var results []*model.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+		results = append(results, attachment)
+	}
+	return results, rows.Err()
+}
+
+// AppendChunk saves chunk and new upload state of attachment if chunk starts at current attachment offset,
+// returns false if another chunk was appended meanwhile
+func (a *Attachment) AppendChunk(ctx context.Context, attachment *model.Attachment, chunk *model.AttachmentChunk) (
+	bool, error) {
+	appended := false
+	err := a.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `UPDATE attachment SET "offset" = $3, chunks = $4, hash_state = $5, checksum = $6,
+			completed_at = $7 WHERE id = $1 AND "offset" = $2`, attachment.ID, chunk.Offset, attachment.Offset,
+			attachment.Chunks, attachment.HashState, attachment.Checksum, attachment.CompletedAt)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+		_, err = tx.Exec(ctx, `INSERT INTO attachment_chunk (attachment_id, chunk_index, "offset", size, storage_key)
+			VALUES ($1, $2, $3, $4, $5)`, chunk.AttachmentID, chunk.Index, chunk.Offset, chunk.Size, chunk.StorageKey)
+		appended = err == nil
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("cannot append Attachment chunk: %v", err)
+	}
+	return appended, nil
+}
+
+// GetChunks gets chunks of attachment in file order
+func (a *Attachment) GetChunks(ctx context.Context, attachmentID uuid.UUID) ([]*model.AttachmentChunk, error) {
+	rows, err := a.db.Query(ctx, `SELECT attachment_id, chunk_index, "offset", size, storage_key
+		FROM attachment_chunk WHERE attachment_id = $1 ORDER BY chunk_index`, attachmentID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var results []*model.AttachmentChunk
+	for rows.Next() {
+		chunk := new(model.AttachmentChunk)
+		err = rows.Scan(&chunk.AttachmentID, &chunk.Index, &chunk.Offset, &chunk.Size, &chunk.StorageKey)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+		results = append(results, chunk)
+	}
+	return results, rows.Err()
+}
+
+// GetChunkKeys gets storage keys of all attachment chunks
+func (a *Attachment) GetChunkKeys(ctx context.Context) ([]string, error) {
+	rows, err := a.db.Query(ctx, "SELECT storage_key FROM attachment_chunk")
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		err = rows.Scan(&key)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// DeleteIncomplete deletes attachments created before createdBefore whose upload isn't complete together with
+// their chunks, returns count of deleted attachments. Chunk blobs are left to attachment garbage collection.
+func (a *Attachment) DeleteIncomplete(ctx context.Context, createdBefore time.Time) (int64, error) {
+	tag, err := a.db.Exec(ctx, "DELETE FROM attachment WHERE completed_at IS NULL AND created_at < $1",
+		createdBefore)
+	if err != nil {
+		return 0, fmt.Errorf("cannot delete incomplete Attachments: %v", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func scanAttachment(row pgx.Row) (*model.Attachment, error) {
+	attachment := new(model.Attachment)
+	err := row.Scan(&attachment.ID, &attachment.CompanyID, &attachment.FileName, &attachment.ContentType,
+		&attachment.Size, &attachment.Offset, &attachment.Chunks, &attachment.HashState, &attachment.Checksum, &attachment.CreatedAt,
+		&attachment.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/Entetry/gocompany/internal/event"
 	"github.com/Entetry/gocompany/internal/model"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4"
@@ -69,30 +70,55 @@ func (c *Company) GetOne(ctx context.Context, id uuid.UUID) (*model.Company, err
 	return &company, err
 }
 
-// Create creates New Company record in db
+// Create creates New Company record in db together with its CompanyCreated event
 func (c *Company) Create(ctx context.Context, company *model.Company) (uuid.UUID, error) {
 	company.ID = uuid.New()
-	_, err := c.db.Exec(ctx, "INSERT INTO company(id, name) VALUES ($1, $2) RETURNING id, name;",
-		company.ID, company.Name)
+	err := c.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "INSERT INTO company(id, name) VALUES ($1, $2) RETURNING id, name;",
+			company.ID, company.Name)
+		if err != nil {
+			return err
+		}
+		return addOutboxEvent(ctx, tx, &event.CompanyCreated{CompanyID: company.ID, Name: company.Name})
+	})
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("cannot create Company: %v", err)
 	}
 	return company.ID, err
 }
 
-// Update updates company in db
+// Update updates company in db together with its CompanyUpdated event
 func (c *Company) Update(ctx context.Context, company *model.Company) error {
-	_, err := c.db.Exec(ctx, "UPDATE company SET name = $2 WHERE id=$1 RETURNING id, name;",
-		company.ID, company.Name)
+	err := c.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE company SET name = $2 WHERE id=$1 RETURNING id, name;",
+			company.ID, company.Name)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+		return addOutboxEvent(ctx, tx, &event.CompanyUpdated{CompanyID: company.ID, Name: company.Name})
+	})
 	if err != nil {
 		return fmt.Errorf("cannot update Company: %v", err)
 	}
 	return err
 }
 
-// Delete deletes company from db
+// Delete deletes company from db together with its CompanyDeleted event, all company logo versions and
+// attachments are deleted with it and blobs no longer referenced are left to logo and attachment garbage
+// collection
 func (c *Company) Delete(ctx context.Context, id uuid.UUID) error {
-	_, err := c.db.Exec(ctx, "DELETE FROM company WHERE id = $1", id)
+	err := c.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		// logos are deleted before company, so cascade doesn't skip releasing their blobs
+		err := deleteLogos(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, "DELETE FROM company WHERE id = $1", id)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+		return addOutboxEvent(ctx, tx, &event.CompanyDeleted{CompanyID: id})
+	})
 	if err != nil {
 		return fmt.Errorf("cannot delete Company: %v", err)
 	}
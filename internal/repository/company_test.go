@@ -21,7 +21,7 @@ func TestCompany_Create(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	defer func() {
-		_, err := dbPool.Exec(ctx, "TRUNCATE table company")
+		_, err := dbPool.Exec(ctx, "TRUNCATE table company CASCADE")
 		require.NoError(t, err)
 	}()
 	t.Log("Given the need to test create company.")
@@ -37,7 +37,7 @@ func TestCompany_Delete(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	defer func() {
-		_, err := dbPool.Exec(ctx, "TRUNCATE table company")
+		_, err := dbPool.Exec(ctx, "TRUNCATE table company CASCADE")
 		require.NoError(t, err)
 	}()
 	t.Log("Given the need to test delete company.")
@@ -53,7 +53,7 @@ func TestCompany_Update(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	defer func() {
-		_, err := dbPool.Exec(ctx, "TRUNCATE table company")
+		_, err := dbPool.Exec(ctx, "TRUNCATE table company CASCADE")
 		require.NoError(t, err)
 	}()
 	t.Log("Given the need to test update company.")
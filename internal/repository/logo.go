@@ -8,13 +8,24 @@ import (
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 
+	"github.com/Entetry/gocompany/internal/event"
 	"github.com/Entetry/gocompany/internal/model"
 )
 
-// LogoRepository company logo repository interface
+const logoColumns = "id, company_id, storage_key, content_type, version, current, created_at"
+
+// LogoRepository company logo repository interface. Every uploaded logo is kept as a version, identical logos
+// share a blob and repository counts references to it.
 type LogoRepository interface {
-	Create(ctx context.Context, companyID uuid.UUID, url string) error
+	Create(ctx context.Context, companyID uuid.UUID, storageKey, contentType string) error
 	GetByCompanyID(ctx context.Context, companyID uuid.UUID) (*model.Logo, error)
+	GetVersions(ctx context.Context, companyID uuid.UUID) ([]*model.Logo, error)
+	GetVersion(ctx context.Context, companyID uuid.UUID, version int) (*model.Logo, error)
+	GetBlobs(ctx context.Context) ([]*model.LogoBlob, error)
+	GetBlob(ctx context.Context, storageKey string) (*model.LogoBlob, error)
+	Replace(ctx context.Context, companyID uuid.UUID, storageKey, contentType string) error
+	Rollback(ctx context.Context, companyID uuid.UUID, version int) (*model.Logo, error)
+	Delete(ctx context.Context, companyID uuid.UUID) (*model.Logo, error)
 }
 
 // Logo company logo postgres repository struct
@@ -28,14 +39,19 @@ func NewLogoRepository(db *pgxpool.Pool) *Logo {
 		db: db}
 }
 
-// Create creates company logo record in db
-func (l *Logo) Create(ctx context.Context, companyID uuid.UUID, url string) error {
-	var logo model.Logo
-	logo.ID = uuid.New()
-	logo.CompanyID = companyID
-	logo.Image = url
-	_, err := l.db.Exec(ctx, `INSERT INTO logo (id, company_id, image) VALUES ($1, $2, $3)`, logo.ID,
-		logo.CompanyID, logo.Image)
+// Create adds logo as new current version together with its LogoAdded event, company must have no current logo
+func (l *Logo) Create(ctx context.Context, companyID uuid.UUID, storageKey, contentType string) error {
+	err := l.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		err := lockLogos(ctx, tx, companyID)
+		if err != nil {
+			return err
+		}
+		logoID, err := addVersion(ctx, tx, companyID, storageKey, contentType)
+		if err != nil {
+			return err
+		}
+		return addOutboxEvent(ctx, tx, &event.LogoAdded{CompanyID: companyID, LogoID: logoID})
+	})
 
 	if err != nil {
 		return fmt.Errorf("cannot create Logo: %v", err)
@@ -43,16 +59,276 @@ func (l *Logo) Create(ctx context.Context, companyID uuid.UUID, url string) erro
 	return nil
 }
 
-// GetByCompanyID gets company logo by company uuid
+// GetByCompanyID gets current company logo by company uuid
 func (l *Logo) GetByCompanyID(ctx context.Context, companyID uuid.UUID) (*model.Logo, error) {
-	var logo model.Logo
-	err := l.db.QueryRow(ctx, "SELECT id, company_id, image FROM logo WHERE company_id = $1", companyID).
-		Scan(&logo.ID, &logo.CompanyID, &logo.Image)
+	logo, err := scanLogo(l.db.QueryRow(ctx, `SELECT `+logoColumns+` FROM logo
+		WHERE company_id = $1 AND current`, companyID))
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("GetByCompanyID failed: %v", err)
 	}
-	return &logo, nil
+	return logo, nil
+}
+
+// GetVersions gets all logo versions of company, latest first
+func (l *Logo) GetVersions(ctx context.Context, companyID uuid.UUID) ([]*model.Logo, error) {
+	return l.query(ctx, `SELECT `+logoColumns+` FROM logo WHERE company_id = $1 ORDER BY version DESC`,
+		companyID)
+}
+
+// GetVersion gets company logo version, nil if there is no such version
+func (l *Logo) GetVersion(ctx context.Context, companyID uuid.UUID, version int) (*model.Logo, error) {
+	logo, err := scanLogo(l.db.QueryRow(ctx, `SELECT `+logoColumns+` FROM logo
+		WHERE company_id = $1 AND version = $2`, companyID, version))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetVersion failed: %v", err)
+	}
+	return logo, nil
+}
+
+// GetBlobs gets all referenced logo blobs
+func (l *Logo) GetBlobs(ctx context.Context) ([]*model.LogoBlob, error) {
+	rows, err := l.db.Query(ctx, "SELECT storage_key, content_type, ref_count FROM logo_blob")
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var results []*model.LogoBlob
+	for rows.Next() {
+		blob := new(model.LogoBlob)
+		err = rows.Scan(&blob.StorageKey, &blob.ContentType, &blob.RefCount)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+		results = append(results, blob)
+	}
+	return results, rows.Err()
+}
+
+// GetBlob gets logo blob, nil if no logo version references it
+func (l *Logo) GetBlob(ctx context.Context, storageKey string) (*model.LogoBlob, error) {
+	blob := new(model.LogoBlob)
+	err := l.db.QueryRow(ctx, "SELECT storage_key, content_type, ref_count FROM logo_blob WHERE storage_key = $1",
+		storageKey).Scan(&blob.StorageKey, &blob.ContentType, &blob.RefCount)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetBlob failed: %v", err)
+	}
+	return blob, nil
+}
+
+// Replace adds logo as new current version together with its LogoReplaced event, previous logo is kept as
+// older version. Emits LogoAdded if company had no current logo.
+func (l *Logo) Replace(ctx context.Context, companyID uuid.UUID, storageKey, contentType string) error {
+	err := l.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		err := lockLogos(ctx, tx, companyID)
+		if err != nil {
+			return err
+		}
+		previousID, err := unsetCurrent(ctx, tx, companyID)
+		if err != nil {
+			return err
+		}
+		logoID, err := addVersion(ctx, tx, companyID, storageKey, contentType)
+		if err != nil {
+			return err
+		}
+		if previousID == uuid.Nil {
+			return addOutboxEvent(ctx, tx, &event.LogoAdded{CompanyID: companyID, LogoID: logoID})
+		}
+		return addOutboxEvent(ctx, tx, &event.LogoReplaced{CompanyID: companyID, LogoID: logoID,
+			PreviousLogoID: previousID})
+	})
+	if err != nil {
+		return fmt.Errorf("cannot replace Logo: %v", err)
+	}
+	return nil
+}
+
+// Rollback makes company logo version current again together with its LogoReplaced event, or LogoAdded event
+// if company had no current logo. Returns the version, nil if there is no such version.
+func (l *Logo) Rollback(ctx context.Context, companyID uuid.UUID, version int) (*model.Logo, error) {
+	var logo *model.Logo
+	err := l.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		err := lockLogos(ctx, tx, companyID)
+		if err != nil {
+			return err
+		}
+		logo, err = scanLogo(tx.QueryRow(ctx, `SELECT `+logoColumns+` FROM logo
+			WHERE company_id = $1 AND version = $2`, companyID, version))
+		if err == pgx.ErrNoRows {
+			logo = nil
+			return nil
+		}
+		if err != nil || logo.Current {
+			return err
+		}
+
+		previousID, err := unsetCurrent(ctx, tx, companyID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "UPDATE logo SET current = true WHERE id = $1", logo.ID)
+		if err != nil {
+			return err
+		}
+		logo.Current = true
+		if previousID == uuid.Nil {
+			return addOutboxEvent(ctx, tx, &event.LogoAdded{CompanyID: companyID, LogoID: logo.ID})
+		}
+		return addOutboxEvent(ctx, tx, &event.LogoReplaced{CompanyID: companyID, LogoID: logo.ID,
+			PreviousLogoID: previousID})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot roll back Logo: %v", err)
+	}
+	return logo, nil
+}
+
+// Delete unsets current company logo together with its LogoDeleted event, logo is kept as older version.
+// Returns unset logo, nil if company had no current logo.
+func (l *Logo) Delete(ctx context.Context, companyID uuid.UUID) (*model.Logo, error) {
+	var deleted *model.Logo
+	err := l.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		var err error
+		deleted, err = scanLogo(tx.QueryRow(ctx, `UPDATE logo SET current = false
+			WHERE company_id = $1 AND current RETURNING `+logoColumns, companyID))
+		if err == pgx.ErrNoRows {
+			deleted = nil
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return addOutboxEvent(ctx, tx, &event.LogoDeleted{CompanyID: companyID, LogoID: deleted.ID})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot delete Logo: %v", err)
+	}
+	return deleted, nil
+}
+
+func (l *Logo) query(ctx context.Context, sql string, args ...interface{}) ([]*model.Logo, error) {
+	rows, err := l.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var results []*model.Logo
+	for rows.Next() {
+		logo, err := scanLogo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+		results = append(results, logo)
+	}
+	return results, rows.Err()
+}
+
+func scanLogo(row pgx.Row) (*model.Logo, error) {
+	logo := new(model.Logo)
+	err := row.Scan(&logo.ID, &logo.CompanyID, &logo.StorageKey, &logo.ContentType, &logo.Version, &logo.Current,
+		&logo.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return logo, nil
+}
+
+// lockLogos serializes changes of company logos within tx, company may have no logo rows to lock yet
+func lockLogos(ctx context.Context, tx pgx.Tx, companyID uuid.UUID) error {
+	_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0))", companyID)
+	return err
+}
+
+// unsetCurrent makes current company logo an older version, returns its id, uuid.Nil if there was none
+func unsetCurrent(ctx context.Context, tx pgx.Tx, companyID uuid.UUID) (uuid.UUID, error) {
+	var previousID uuid.UUID
+	err := tx.QueryRow(ctx, `UPDATE logo SET current = false WHERE company_id = $1 AND current
+		RETURNING id`, companyID).Scan(&previousID)
+	if err == pgx.ErrNoRows {
+		return uuid.Nil, nil
+	}
+	return previousID, err
+}
+
+// addVersion inserts logo as next current version of company logo, fails if company has current logo.
+// Company logos must be locked.
+func addVersion(ctx context.Context, tx pgx.Tx, companyID uuid.UUID, storageKey, contentType string) (uuid.UUID,
+	error) {
+	logoID := uuid.New()
+	_, err := tx.Exec(ctx, `INSERT INTO logo (id, company_id, storage_key, content_type, version, current)
+		SELECT $1, $2, $3, $4, coalesce(max(version), 0) + 1, true FROM logo WHERE company_id = $2`,
+		logoID, companyID, storageKey, contentType)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return logoID, addBlobRef(ctx, tx, storageKey, contentType)
+}
+
+// deleteLogos deletes all company logo versions within tx together with LogoDeleted event of current one,
+// shared with company deletion. Blobs no longer referenced are left to logo garbage collection.
+func deleteLogos(ctx context.Context, tx pgx.Tx, companyID uuid.UUID) error {
+	err := lockLogos(ctx, tx, companyID)
+	if err != nil {
+		return err
+	}
+	rows, err := tx.Query(ctx, `DELETE FROM logo WHERE company_id = $1 RETURNING `+logoColumns, companyID)
+	if err != nil {
+		return err
+	}
+	var deleted []*model.Logo
+	for rows.Next() {
+		logo, err := scanLogo(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		deleted = append(deleted, logo)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	for _, logo := range deleted {
+		err = releaseBlobRef(ctx, tx, logo.StorageKey)
+		if err != nil {
+			return err
+		}
+		if logo.Current {
+			err = addOutboxEvent(ctx, tx, &event.LogoDeleted{CompanyID: companyID, LogoID: logo.ID})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addBlobRef counts new reference to logo blob
+func addBlobRef(ctx context.Context, tx pgx.Tx, storageKey, contentType string) error {
+	_, err := tx.Exec(ctx, `INSERT INTO logo_blob (storage_key, content_type, ref_count) VALUES ($1, $2, 1)
+		ON CONFLICT (storage_key) DO UPDATE SET ref_count = logo_blob.ref_count + 1`, storageKey, contentType)
+	return err
+}
+
+// releaseBlobRef drops reference to logo blob, row of the last one is deleted
+func releaseBlobRef(ctx context.Context, tx pgx.Tx, storageKey string) error {
+	tag, err := tx.Exec(ctx, `UPDATE logo_blob SET ref_count = ref_count - 1
+		WHERE storage_key = $1 AND ref_count > 1`, storageKey)
+	if err != nil || tag.RowsAffected() > 0 {
+		return err
+	}
+	_, err = tx.Exec(ctx, "DELETE FROM logo_blob WHERE storage_key = $1", storageKey)
+	return err
 }
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+func TestLogo_BlobRefs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		_, err := dbPool.Exec(ctx, "TRUNCATE table company, logo, logo_blob, outbox CASCADE")
+		require.NoError(t, err)
+	}()
+	t.Log("Given the need to test references to shared logo blobs are counted.")
+	logoRepository := NewLogoRepository(dbPool)
+	first, err := companyRepository.Create(ctx, &model.Company{Name: "Google"})
+	require.NoError(t, err, "tested create function error")
+	second, err := companyRepository.Create(ctx, &model.Company{Name: "Apple"})
+	require.NoError(t, err, "tested create function error")
+
+	const storageKey = "logo/shared.png"
+	require.NoError(t, logoRepository.Create(ctx, first, storageKey, "image/png"))
+	require.NoError(t, logoRepository.Replace(ctx, second, storageKey, "image/png"))
+	require.NoError(t, logoRepository.Replace(ctx, second, "logo/other.png", "image/png"))
+	blob, err := logoRepository.GetBlob(ctx, storageKey)
+	require.NoError(t, err)
+	require.Equal(t, 2, blob.RefCount)
+
+	require.NoError(t, companyRepository.Delete(ctx, second))
+	blob, err = logoRepository.GetBlob(ctx, storageKey)
+	require.NoError(t, err)
+	require.Equal(t, 1, blob.RefCount, "older version of deleted company released its reference")
+	blob, err = logoRepository.GetBlob(ctx, "logo/other.png")
+	require.NoError(t, err)
+	require.Nil(t, blob, "blob without references has no row")
+
+	require.NoError(t, companyRepository.Delete(ctx, first))
+	blobs, err := logoRepository.GetBlobs(ctx)
+	require.NoError(t, err)
+	require.Empty(t, blobs)
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/Entetry/gocompany/internal/event"
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+const (
+	// outboxLockKey advisory lock key, claims are made one at a time so relays don't claim the same events
+	outboxLockKey   = 2022100401
+	outboxMaxDelay  = 5 * time.Minute
+	outboxBaseDelay = time.Second
+	// outboxClaimTTL events claimed by relay which died while publishing are claimed again after that
+	outboxClaimTTL = time.Minute
+	// outboxMaxAttempts event is marked failed after that many failed publishes, about 4 hours with max delay
+	outboxMaxAttempts = 50
+)
+
+// ErrUnpublishable publish error of outbox event which can never be published, event is marked failed at once
+var ErrUnpublishable = errors.New("outbox event can't be published")
+
+// OutboxRepository company events outbox repository interface
+type OutboxRepository interface {
+	ProcessPending(ctx context.Context, limit int, publish func(ctx context.Context, event *model.OutboxEvent) error) (int, error)
+}
+
+// Outbox company events outbox postgres repository struct
+type Outbox struct {
+	db *pgxpool.Pool
+}
+
+// NewOutboxRepository creates new outbox repository object
+func NewOutboxRepository(db *pgxpool.Pool) *Outbox {
+	return &Outbox{db: db}
+}
+
+// ProcessPending claims up to limit due events and passes them to publish in order they were added,
+// published events are removed and failed ones are retried later with backoff.
+// After a failure later events of the same company wait too. Events failed with ErrUnpublishable or
+// outboxMaxAttempts times are marked failed and don't hold back later ones. Returns count of published events.
+// Events are published outside of claim transaction, so slow publishing doesn't hold db locks.
+func (o *Outbox) ProcessPending(ctx context.Context, limit int,
+	publish func(ctx context.Context, event *model.OutboxEvent) error) (int, error) {
+	events, err := o.claim(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("cannot claim outbox events: %v", err)
+	}
+
+	var count int
+	blocked := make(map[uuid.UUID]struct{})
+	for _, e := range events {
+		if _, ok := blocked[e.CompanyID]; ok {
+			err = o.release(ctx, e)
+		} else if publishErr := publish(ctx, e); publishErr != nil {
+			if errors.Is(publishErr, ErrUnpublishable) || e.Attempts+1 >= outboxMaxAttempts {
+				err = o.markDead(ctx, e, publishErr)
+			} else {
+				blocked[e.CompanyID] = struct{}{}
+				err = o.markFailed(ctx, e, publishErr)
+			}
+		} else {
+			count++
+			_, err = o.db.Exec(ctx, "DELETE FROM outbox WHERE id = $1", e.ID)
+		}
+		if err != nil {
+			return count, fmt.Errorf("cannot process outbox: %v", err)
+		}
+	}
+	return count, nil
+}
+
+// claim marks due events as being published. Event is due when its retry time has come and no earlier event
+// of its company waits for retry or is being published, so one failing company doesn't hold back others
+func (o *Outbox) claim(ctx context.Context, limit int) ([]*model.OutboxEvent, error) {
+	var events []*model.OutboxEvent
+	err := o.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		var locked bool
+		err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", outboxLockKey).Scan(&locked)
+		if err != nil || !locked {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, `UPDATE outbox SET claimed_until = now() + $2::interval
+			WHERE id IN (
+				SELECT o.id FROM outbox o
+				WHERE o.failed_at IS NULL AND o.next_attempt_at <= now()
+				  AND (o.claimed_until IS NULL OR o.claimed_until < now())
+				  AND NOT EXISTS (SELECT 1 FROM outbox earlier
+					WHERE earlier.company_id = o.company_id AND earlier.id < o.id AND earlier.failed_at IS NULL
+					  AND (earlier.next_attempt_at > now() OR earlier.claimed_until >= now()))
+				ORDER BY o.id LIMIT $1)
+			RETURNING id, company_id, event, version, payload, attempts, next_attempt_at`,
+			limit, outboxClaimTTL)
+		if err != nil {
+			return fmt.Errorf("query: %v", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var e model.OutboxEvent
+			err = rows.Scan(&e.ID, &e.CompanyID, &e.Event, &e.Version, &e.Payload, &e.Attempts, &e.NextAttemptAt)
+			if err != nil {
+				return fmt.Errorf("scan: %v", err)
+			}
+			events = append(events, &e)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	// UPDATE ... RETURNING doesn't keep order of subquery
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+// release returns claimed event unpublished, it waits for earlier failed event of its company
+func (o *Outbox) release(ctx context.Context, e *model.OutboxEvent) error {
+	_, err := o.db.Exec(ctx, "UPDATE outbox SET claimed_until = NULL WHERE id = $1", e.ID)
+	return err
+}
+
+func (o *Outbox) markFailed(ctx context.Context, e *model.OutboxEvent, publishErr error) error {
+	delay := outboxBaseDelay << e.Attempts
+	if e.Attempts > 16 || delay > outboxMaxDelay {
+		delay = outboxMaxDelay
+	}
+	_, err := o.db.Exec(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3,
+		claimed_until = NULL WHERE id = $1`, e.ID, publishErr.Error(), time.Now().Add(delay))
+	return err
+}
+
+// markDead keeps event which won't be published as failed, it's skipped by later claims
+func (o *Outbox) markDead(ctx context.Context, e *model.OutboxEvent, publishErr error) error {
+	_, err := o.db.Exec(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = $2, failed_at = now(),
+		claimed_until = NULL WHERE id = $1`, e.ID, publishErr.Error())
+	return err
+}
+
+// addOutboxEvent stores company event within transaction of company change
+func addOutboxEvent(ctx context.Context, tx pgx.Tx, e event.Event) error {
+	payload, err := event.Encode(e)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO outbox (company_id, event, version, payload) VALUES ($1, $2, $3, $4)",
+		e.AggregateID(), e.Type(), event.SchemaVersion, payload)
+	if err != nil {
+		return fmt.Errorf("cannot add outbox event: %v", err)
+	}
+	return nil
+}
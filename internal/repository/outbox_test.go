@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/event"
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+func TestOutbox_ProcessPending(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		_, err := dbPool.Exec(ctx, "TRUNCATE table company, outbox CASCADE")
+		require.NoError(t, err)
+	}()
+	t.Log("Given the need to test publishing of company events from outbox.")
+	outboxRepository := NewOutboxRepository(dbPool)
+	id, err := companyRepository.Create(ctx, &model.Company{Name: "Google"})
+	require.NoError(t, err, "tested create function error")
+	err = companyRepository.Update(ctx, &model.Company{ID: id, Name: "Alphabet"})
+	require.NoError(t, err, "tested update function error")
+
+	count, err := outboxRepository.ProcessPending(ctx, 10, func(ctx context.Context, e *model.OutboxEvent) error {
+		return errors.New("redis is down")
+	})
+	require.NoError(t, err, "failed publish must not fail processing")
+	require.Equal(t, 0, count)
+
+	_, err = dbPool.Exec(ctx, "UPDATE outbox SET next_attempt_at = now()")
+	require.NoError(t, err)
+	var published []*model.OutboxEvent
+	count, err = outboxRepository.ProcessPending(ctx, 10, func(ctx context.Context, e *model.OutboxEvent) error {
+		published = append(published, e)
+		return nil
+	})
+	require.NoError(t, err, "tested process function error")
+	require.Equal(t, 2, count)
+	require.Equal(t, event.CREATE, published[0].Event)
+	require.Equal(t, 1, published[0].Attempts)
+	require.Equal(t, event.UPDATE, published[1].Event)
+	require.Equal(t, event.SchemaVersion, published[1].Version)
+	updated, err := event.Decode(published[1].Event, published[1].Version, published[1].CompanyID, published[1].Payload)
+	require.NoError(t, err, "outbox payload must follow event contract")
+	require.Equal(t, "Alphabet", updated.(*event.CompanyUpdated).Name)
+}
+
+func TestOutbox_ProcessPendingBlockedCompany(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		_, err := dbPool.Exec(ctx, "TRUNCATE table company, outbox CASCADE")
+		require.NoError(t, err)
+	}()
+	t.Log("Given the need to test that company waiting for retry doesn't hold back events of other companies.")
+	outboxRepository := NewOutboxRepository(dbPool)
+	failedID, err := companyRepository.Create(ctx, &model.Company{Name: "Google"})
+	require.NoError(t, err, "tested create function error")
+	_, err = outboxRepository.ProcessPending(ctx, 10, func(ctx context.Context, e *model.OutboxEvent) error {
+		return errors.New("redis is down")
+	})
+	require.NoError(t, err, "failed publish must not fail processing")
+
+	err = companyRepository.Update(ctx, &model.Company{ID: failedID, Name: "Alphabet"})
+	require.NoError(t, err, "tested update function error")
+	id, err := companyRepository.Create(ctx, &model.Company{Name: "Apple"})
+	require.NoError(t, err, "tested create function error")
+
+	var published []*model.OutboxEvent
+	count, err := outboxRepository.ProcessPending(ctx, 1, func(ctx context.Context, e *model.OutboxEvent) error {
+		published = append(published, e)
+		return nil
+	})
+	require.NoError(t, err, "tested process function error")
+	require.Equal(t, 1, count)
+	require.Equal(t, id, published[0].CompanyID)
+	require.Equal(t, event.CREATE, published[0].Event)
+
+	var pending int
+	err = dbPool.QueryRow(ctx, "SELECT count(*) FROM outbox WHERE company_id = $1", failedID).Scan(&pending)
+	require.NoError(t, err)
+	require.Equal(t, 2, pending, "events of failed company must wait for retry")
+}
+
+func TestOutbox_ProcessPendingPoisonEvent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		_, err := dbPool.Exec(ctx, "TRUNCATE table company, outbox CASCADE")
+		require.NoError(t, err)
+	}()
+	t.Log("Given the need to test event which can't be published is marked failed and doesn't block company.")
+	outboxRepository := NewOutboxRepository(dbPool)
+	id, err := companyRepository.Create(ctx, &model.Company{Name: "Google"})
+	require.NoError(t, err, "tested create function error")
+	err = companyRepository.Update(ctx, &model.Company{ID: id, Name: "Alphabet"})
+	require.NoError(t, err, "tested update function error")
+	err = companyRepository.Update(ctx, &model.Company{ID: id, Name: "Alphabet Inc"})
+	require.NoError(t, err, "tested update function error")
+	// second event already failed all but last attempt
+	_, err = dbPool.Exec(ctx, `UPDATE outbox SET attempts = $1
+		WHERE id = (SELECT id FROM outbox ORDER BY id OFFSET 1 LIMIT 1)`, outboxMaxAttempts-1)
+	require.NoError(t, err)
+
+	var published []*model.OutboxEvent
+	count, err := outboxRepository.ProcessPending(ctx, 10, func(ctx context.Context, e *model.OutboxEvent) error {
+		switch e.Event {
+		case event.CREATE:
+			return fmt.Errorf("%w: broken payload", ErrUnpublishable)
+		case event.UPDATE:
+			if e.Attempts > 0 {
+				return errors.New("redis is down")
+			}
+		}
+		published = append(published, e)
+		return nil
+	})
+	require.NoError(t, err, "tested process function error")
+	require.Equal(t, 1, count, "later event is published after failed ones")
+	require.Len(t, published, 1)
+
+	var failed int
+	err = dbPool.QueryRow(ctx, "SELECT count(*) FROM outbox WHERE failed_at IS NOT NULL").Scan(&failed)
+	require.NoError(t, err)
+	require.Equal(t, 2, failed, "failed events are kept for inspection")
+	count, err = outboxRepository.ProcessPending(ctx, 10, func(ctx context.Context, e *model.OutboxEvent) error {
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, count, "failed events aren't claimed again")
+}
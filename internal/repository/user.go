@@ -6,16 +6,33 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 
 	"github.com/Entetry/gocompany/internal/model"
 )
 
+const (
+	userColumns = "id, username, email, passwordHash, status"
+	// uniqueViolation postgres error code of unique constraint violation
+	uniqueViolation = "23505"
+	usernameKey     = "users_username_key"
+)
+
+// ErrDuplicateUsername another user has the username
+var ErrDuplicateUsername = errors.New("duplicate username")
+
 // UserRepository user repository interface
 type UserRepository interface {
-	Create(ctx context.Context, username, pwdHash, email string) (uuid.UUID, error)
+	Create(ctx context.Context, username, pwdHash, email, status string) (uuid.UUID, error)
 	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) ([]*model.User, error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, status string) error
+	UpdatePassword(ctx context.Context, id uuid.UUID, pwdHash string) error
+	Update(ctx context.Context, user *model.User) error
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 // User User postgres repository struct
@@ -31,14 +48,18 @@ func NewUserRepository(db *pgxpool.Pool) *User {
 }
 
 // Create insert user record in db
-func (u *User) Create(ctx context.Context, username, pwdHash, email string) (uuid.UUID, error) {
+func (u *User) Create(ctx context.Context, username, pwdHash, email, status string) (uuid.UUID, error) {
 	var user model.User
 	user.ID = uuid.New()
 	user.PasswordHash = pwdHash
 	user.Email = email
 	user.Username = username
-	_, err := u.db.Exec(ctx, `INSERT INTO users (id, username, email, passwordHash) VALUES ($1, $2, $3, $4)`,
-		user.ID, user.Username, user.Email, user.PasswordHash)
+	user.Status = status
+	_, err := u.db.Exec(ctx, `INSERT INTO users (id, username, email, passwordHash, status)
+		VALUES ($1, $2, $3, $4, $5)`, user.ID, user.Username, user.Email, user.PasswordHash, user.Status)
+	if isUsernameTaken(err) {
+		return uuid.Nil, ErrDuplicateUsername
+	}
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("cannot create User: %v", err)
 	}
@@ -47,10 +68,7 @@ func (u *User) Create(ctx context.Context, username, pwdHash, email string) (uui
 
 // GetByUsername return user by his username
 func (u *User) GetByUsername(ctx context.Context, username string) (*model.User, error) {
-	var user model.User
-	err := u.db.QueryRow(ctx,
-		`SELECT id, username, email, passwordHash FROM users WHERE username = $1`, username).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash)
+	user, err := scanUser(u.db.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE username = $1`, username))
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
 	}
@@ -58,5 +76,98 @@ func (u *User) GetByUsername(ctx context.Context, username string) (*model.User,
 	if err != nil {
 		return nil, fmt.Errorf("error in GetByUsername: %v", err)
 	}
-	return &user, nil
+	return user, nil
+}
+
+// GetByID return user by id, nil if there is no such user
+func (u *User) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	user, err := scanUser(u.db.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error in GetByID: %v", err)
+	}
+	return user, nil
+}
+
+// GetByEmail return users with email, compared case-insensitively
+func (u *User) GetByEmail(ctx context.Context, email string) ([]*model.User, error) {
+	rows, err := u.db.Query(ctx, `SELECT `+userColumns+` FROM users WHERE lower(email) = lower($1)`, email)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var results []*model.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+		results = append(results, user)
+	}
+	return results, rows.Err()
+}
+
+// UpdateStatus sets user status
+func (u *User) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
+	_, err := u.db.Exec(ctx, "UPDATE users SET status = $2 WHERE id = $1", id, status)
+	if err != nil {
+		return fmt.Errorf("cannot update User status: %v", err)
+	}
+	return nil
+}
+
+// UpdatePassword sets user password hash
+func (u *User) UpdatePassword(ctx context.Context, id uuid.UUID, pwdHash string) error {
+	_, err := u.db.Exec(ctx, "UPDATE users SET passwordHash = $2 WHERE id = $1", id, pwdHash)
+	if err != nil {
+		return fmt.Errorf("cannot update User password: %v", err)
+	}
+	return nil
+}
+
+// Update sets username, email and status of user
+func (u *User) Update(ctx context.Context, user *model.User) error {
+	_, err := u.db.Exec(ctx, "UPDATE users SET username = $2, email = $3, status = $4 WHERE id = $1",
+		user.ID, user.Username, user.Email, user.Status)
+	if isUsernameTaken(err) {
+		return ErrDuplicateUsername
+	}
+	if err != nil {
+		return fmt.Errorf("cannot update User: %v", err)
+	}
+	return nil
+}
+
+// Delete deletes user with its refresh sessions, tokens are deleted by cascade
+func (u *User) Delete(ctx context.Context, id uuid.UUID) error {
+	err := u.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "DELETE FROM refresh_sessions WHERE user_id = $1", id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM users WHERE id = $1", id)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("cannot delete User: %v", err)
+	}
+	return nil
+}
+
+func scanUser(row pgx.Row) (*model.User, error) {
+	user := new(model.User)
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.Status)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// isUsernameTaken reports whether err is violation of unique username index
+func isUsernameTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == usernameKey
 }
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+// UserTokenRepository repository interface of single-use user tokens
+type UserTokenRepository interface {
+	Create(ctx context.Context, token *model.UserToken) error
+	Consume(ctx context.Context, hash, purpose string) (uuid.UUID, error)
+	GetUserID(ctx context.Context, hash, purpose string) (uuid.UUID, error)
+	DeleteByUser(ctx context.Context, userID uuid.UUID, purpose string) error
+	LastCreatedAt(ctx context.Context, userID uuid.UUID, purpose string) (*time.Time, error)
+}
+
+// UserToken user token postgres repository struct
+type UserToken struct {
+	db *pgxpool.Pool
+}
+
+// NewUserTokenRepository creates new user token repository object
+func NewUserTokenRepository(db *pgxpool.Pool) *UserToken {
+	return &UserToken{db: db}
+}
+
+// Create inserts token
+func (u *UserToken) Create(ctx context.Context, token *model.UserToken) error {
+	_, err := u.db.Exec(ctx, `INSERT INTO user_token (token_hash, user_id, purpose, expires_at)
+		VALUES ($1, $2, $3, $4)`, token.Hash, token.UserID, token.Purpose, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("cannot create UserToken: %v", err)
+	}
+	return nil
+}
+
+// Consume marks unused unexpired token as used and returns its user, uuid.Nil if there is no such token
+func (u *UserToken) Consume(ctx context.Context, hash, purpose string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := u.db.QueryRow(ctx, `UPDATE user_token SET used_at = now()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > now()
+		RETURNING user_id`, hash, purpose).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, nil
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("cannot consume UserToken: %v", err)
+	}
+	return userID, nil
+}
+
+// GetUserID returns user of unused unexpired token without using it up, uuid.Nil if there is no such token
+func (u *UserToken) GetUserID(ctx context.Context, hash, purpose string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := u.db.QueryRow(ctx, `SELECT user_id FROM user_token
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > now()`, hash, purpose).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, nil
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("cannot get UserToken: %v", err)
+	}
+	return userID, nil
+}
+
+// DeleteByUser deletes all tokens of user with purpose
+func (u *UserToken) DeleteByUser(ctx context.Context, userID uuid.UUID, purpose string) error {
+	_, err := u.db.Exec(ctx, "DELETE FROM user_token WHERE user_id = $1 AND purpose = $2", userID, purpose)
+	if err != nil {
+		return fmt.Errorf("cannot delete UserTokens: %v", err)
+	}
+	return nil
+}
+
+// LastCreatedAt returns creation time of latest user token with purpose, nil if there is none
+func (u *UserToken) LastCreatedAt(ctx context.Context, userID uuid.UUID, purpose string) (*time.Time, error) {
+	var createdAt *time.Time
+	err := u.db.QueryRow(ctx, `SELECT max(created_at) FROM user_token WHERE user_id = $1 AND purpose = $2`,
+		userID, purpose).Scan(&createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get UserToken: %v", err)
+	}
+	return createdAt, nil
+}
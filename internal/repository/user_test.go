@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+func TestUser_DuplicateUsername(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		_, err := dbPool.Exec(ctx, "TRUNCATE table users CASCADE")
+		require.NoError(t, err)
+	}()
+	t.Log("Given the need to test username of another user is rejected by unique index.")
+	userRepository := NewUserRepository(dbPool)
+	daveID, err := userRepository.Create(ctx, "dave", "hash", "dave@example.com", model.UserActive)
+	require.NoError(t, err, "tested create function error")
+	_, err = userRepository.Create(ctx, "erin", "hash", "erin@example.com", model.UserActive)
+	require.NoError(t, err, "tested create function error")
+
+	_, err = userRepository.Create(ctx, "erin", "hash", "erin2@example.com", model.UserActive)
+	require.ErrorIs(t, err, ErrDuplicateUsername)
+	err = userRepository.Update(ctx, &model.User{ID: daveID, Username: "erin", Email: "dave@example.com",
+		Status: model.UserActive})
+	require.ErrorIs(t, err, ErrDuplicateUsername)
+}
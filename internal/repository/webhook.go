@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+// WebhookRepository webhook subscriptions and deliveries repository interface
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *model.WebhookSubscription) error
+	GetSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id uuid.UUID) (*model.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscription *model.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+	Enqueue(ctx context.Context, eventID uuid.UUID, eventType string, payload []byte) (int64, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*model.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt *model.WebhookAttempt,
+		disableAfter int) (bool, error)
+	GetDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]*model.WebhookDelivery, error)
+	GetAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*model.WebhookAttempt, error)
+}
+
+// Webhook webhook postgres repository struct
+type Webhook struct {
+	db *pgxpool.Pool
+}
+
+// NewWebhookRepository creates new webhook repository object
+func NewWebhookRepository(db *pgxpool.Pool) *Webhook {
+	return &Webhook{db: db}
+}
+
+// CreateSubscription creates webhook subscription record in db
+func (w *Webhook) CreateSubscription(ctx context.Context, subscription *model.WebhookSubscription) error {
+	subscription.ID = uuid.New()
+	err := w.db.QueryRow(ctx, `INSERT INTO webhook_subscription (id, url, secret, events, enabled)
+		VALUES ($1, $2, $3, $4, $5) RETURNING created_at`,
+		subscription.ID, subscription.URL, subscription.Secret, subscription.Events, subscription.Enabled).
+		Scan(&subscription.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("cannot create webhook subscription: %v", err)
+	}
+	return nil
+}
+
+// GetSubscriptions get all webhook subscriptions from db
+func (w *Webhook) GetSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	rows, err := w.db.Query(ctx, `SELECT id, url, secret, events, enabled, failures, created_at
+		FROM webhook_subscription ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var results []*model.WebhookSubscription
+	for rows.Next() {
+		var s model.WebhookSubscription
+		err = rows.Scan(&s.ID, &s.URL, &s.Secret, &s.Events, &s.Enabled, &s.Failures, &s.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+		results = append(results, &s)
+	}
+	return results, rows.Err()
+}
+
+// GetSubscription gets webhook subscription by its uuid, returns nil if it doesn't exist
+func (w *Webhook) GetSubscription(ctx context.Context, id uuid.UUID) (*model.WebhookSubscription, error) {
+	var s model.WebhookSubscription
+	err := w.db.QueryRow(ctx, `SELECT id, url, secret, events, enabled, failures, created_at
+		FROM webhook_subscription WHERE id = $1`, id).
+		Scan(&s.ID, &s.URL, &s.Secret, &s.Events, &s.Enabled, &s.Failures, &s.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetSubscription failed: %v", err)
+	}
+	return &s, nil
+}
+
+// UpdateSubscription updates url, events and enabled flag of subscription, enabling resets failures
+func (w *Webhook) UpdateSubscription(ctx context.Context, subscription *model.WebhookSubscription) error {
+	_, err := w.db.Exec(ctx, `UPDATE webhook_subscription
+		SET url = $2, events = $3, enabled = $4, failures = CASE WHEN $4 THEN 0 ELSE failures END
+		WHERE id = $1`, subscription.ID, subscription.URL, subscription.Events, subscription.Enabled)
+	if err != nil {
+		return fmt.Errorf("cannot update webhook subscription: %v", err)
+	}
+	return nil
+}
+
+// DeleteSubscription deletes subscription with its deliveries from db
+func (w *Webhook) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	_, err := w.db.Exec(ctx, "DELETE FROM webhook_subscription WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("cannot delete webhook subscription: %v", err)
+	}
+	return nil
+}
+
+// Enqueue creates delivery of event for every enabled subscription interested in it,
+// event already enqueued for subscription is skipped. Returns count of created deliveries.
+func (w *Webhook) Enqueue(ctx context.Context, eventID uuid.UUID, eventType string, payload []byte) (int64, error) {
+	tag, err := w.db.Exec(ctx, `INSERT INTO webhook_delivery (id, subscription_id, event_id, event_type, payload)
+		SELECT gen_random_uuid(), id, $1, $2, $3 FROM webhook_subscription
+		WHERE enabled AND (cardinality(events) = 0 OR $2 = ANY(events))
+		ON CONFLICT (subscription_id, event_id) DO NOTHING`, eventID, eventType, payload)
+	if err != nil {
+		return 0, fmt.Errorf("cannot enqueue webhook deliveries: %v", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ClaimDue takes up to limit due deliveries of enabled subscriptions and hides them from other workers for lease
+func (w *Webhook) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*model.WebhookDelivery, error) {
+	rows, err := w.db.Query(ctx, `UPDATE webhook_delivery d SET next_attempt_at = now() + $2::interval
+		FROM webhook_subscription s
+		WHERE d.subscription_id = s.id AND d.id IN (
+			SELECT d.id FROM webhook_delivery d JOIN webhook_subscription s ON d.subscription_id = s.id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= now() AND s.enabled
+			ORDER BY d.next_attempt_at LIMIT $1 FOR UPDATE OF d SKIP LOCKED)
+		RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.created_at,
+			s.url, s.secret`, limit, lease)
+	if err != nil {
+		return nil, fmt.Errorf("cannot claim webhook deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	var results []*model.WebhookDelivery
+	for rows.Next() {
+		var d model.WebhookDelivery
+		err = rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&d.CreatedAt, &d.URL, &d.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+		results = append(results, &d)
+	}
+	return results, rows.Err()
+}
+
+// RecordAttempt stores attempt and new delivery state, counts consecutive failures of subscription
+// and disables it after disableAfter of them. Returns true if subscription got disabled.
+func (w *Webhook) RecordAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt *model.WebhookAttempt,
+	disableAfter int) (bool, error) {
+	var disabled bool
+	err := w.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `INSERT INTO webhook_attempt (delivery_id, attempt, status_code, error, duration_ms)
+			VALUES ($1, $2, $3, $4, $5)`, delivery.ID, attempt.Attempt, nullInt(attempt.StatusCode),
+			nullString(attempt.Error), attempt.Duration.Milliseconds())
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `UPDATE webhook_delivery SET status = $2, attempts = $3, next_attempt_at = $4,
+			last_status_code = $5, last_error = $6 WHERE id = $1`, delivery.ID, delivery.Status, delivery.Attempts,
+			delivery.NextAttemptAt, nullInt(delivery.LastStatusCode), nullString(delivery.LastError))
+		if err != nil {
+			return err
+		}
+		if attempt.Error == "" {
+			_, err = tx.Exec(ctx, "UPDATE webhook_subscription SET failures = 0 WHERE id = $1", delivery.SubscriptionID)
+			return err
+		}
+		return tx.QueryRow(ctx, `UPDATE webhook_subscription SET failures = failures + 1,
+			enabled = enabled AND failures + 1 < $2 WHERE id = $1 RETURNING NOT enabled`,
+			delivery.SubscriptionID, disableAfter).Scan(&disabled)
+	})
+	if err != nil {
+		return false, fmt.Errorf("cannot record webhook attempt: %v", err)
+	}
+	return disabled, nil
+}
+
+// GetDeliveries returns last deliveries of subscription
+func (w *Webhook) GetDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]*model.WebhookDelivery, error) {
+	rows, err := w.db.Query(ctx, `SELECT id, subscription_id, event_id, event_type, payload, status, attempts,
+		next_attempt_at, coalesce(last_status_code, 0), coalesce(last_error, ''), created_at
+		FROM webhook_delivery WHERE subscription_id = $1 ORDER BY created_at DESC LIMIT $2`, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var results []*model.WebhookDelivery
+	for rows.Next() {
+		var d model.WebhookDelivery
+		err = rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+		results = append(results, &d)
+	}
+	return results, rows.Err()
+}
+
+// GetAttempts returns all attempts of delivery
+func (w *Webhook) GetAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*model.WebhookAttempt, error) {
+	rows, err := w.db.Query(ctx, `SELECT id, delivery_id, attempt, coalesce(status_code, 0), coalesce(error, ''),
+		duration_ms, created_at FROM webhook_attempt WHERE delivery_id = $1 ORDER BY id`, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var results []*model.WebhookAttempt
+	for rows.Next() {
+		var a model.WebhookAttempt
+		var durationMs int64
+		err = rows.Scan(&a.ID, &a.DeliveryID, &a.Attempt, &a.StatusCode, &a.Error, &durationMs, &a.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %v", err)
+		}
+		a.Duration = time.Duration(durationMs) * time.Millisecond
+		results = append(results, &a)
+	}
+	return results, rows.Err()
+}
+
+func nullInt(v int) *int {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
+
+func nullString(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
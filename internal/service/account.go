@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+var (
+	// ErrUserNotFound user of token doesn't exist anymore
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUsernameTaken another user has the username
+	ErrUsernameTaken = errors.New("username is already taken")
+	// ErrWrongPassword current password doesn't match
+	ErrWrongPassword = errors.New("wrong password")
+)
+
+// ProfileUpdate changes of user profile, nil fields are kept
+type ProfileUpdate struct {
+	Username *string
+	Email    *string
+	// CurrentPassword is required to change email
+	CurrentPassword string
+}
+
+// Account service of signed in user managing own account
+type Account struct {
+	userService       *User
+	refreshSession    *RefreshSession
+	emailVerification *EmailVerification
+}
+
+// NewAccount creates new Account service
+func NewAccount(userService *User, refreshSession *RefreshSession, emailVerification *EmailVerification) *Account {
+	return &Account{
+		userService:       userService,
+		refreshSession:    refreshSession,
+		emailVerification: emailVerification,
+	}
+}
+
+// Get returns user
+func (a *Account) Get(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	user, err := a.userService.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// UpdateProfile changes username and email of user. Email is changed only if current password matches,
+// changed email has to be verified again and user is signed out of all sessions
+func (a *Account) UpdateProfile(ctx context.Context, userID uuid.UUID, update *ProfileUpdate) (*model.User, error) {
+	user, err := a.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	emailChanged := update.Email != nil && !strings.EqualFold(*update.Email, user.Email)
+	if emailChanged {
+		err = a.userService.CheckPassword(ctx, user, update.CurrentPassword)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if update.Username != nil && *update.Username != user.Username {
+		other, err := a.userService.GetByUsername(ctx, *update.Username)
+		if err != nil {
+			return nil, err
+		}
+		if other != nil {
+			return nil, ErrUsernameTaken
+		}
+		user.Username = *update.Username
+	}
+	if update.Email != nil {
+		user.Email = *update.Email
+	}
+	if emailChanged {
+		user.Status = model.UserPending
+	}
+
+	err = a.userService.Update(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if emailChanged {
+		err = a.refreshSession.DeleteUserSessions(ctx, user.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		err = a.emailVerification.Send(ctx, user)
+		if err != nil {
+			log.Errorf("cannot send verification email to user %s: %v", user.ID, err)
+		}
+	}
+	return user, nil
+}
+
+// ChangePassword sets new password if current password matches, user is signed out of all sessions
+func (a *Account) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) error {
+	user, err := a.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	err = a.userService.CheckPassword(ctx, user, currentPassword)
+	if err != nil {
+		return err
+	}
+	err = a.userService.ValidatePassword(newPassword, user.Username, user.Email)
+	if err != nil {
+		return err
+	}
+	err = a.userService.UpdatePassword(ctx, user.ID, newPassword)
+	if err != nil {
+		return err
+	}
+	return a.refreshSession.DeleteUserSessions(ctx, user.ID.String())
+}
+
+// Delete deletes user with all sessions if current password matches
+func (a *Account) Delete(ctx context.Context, userID uuid.UUID, currentPassword string) error {
+	user, err := a.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	err = a.userService.CheckPassword(ctx, user, currentPassword)
+	if err != nil {
+		return err
+	}
+	return a.userService.Delete(ctx, userID)
+}
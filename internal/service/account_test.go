@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/password"
+)
+
+func TestAccount(t *testing.T) {
+	t.Log("Given the need to test signed in user views, edits and deletes own account.")
+	f := newAuthFixture()
+	f.mailCfg.VerificationRequired = false
+	ctx := context.Background()
+	require.NoError(t, f.auth.SignUp(ctx, "dave", "old-password", "dave@example.com"))
+	require.NoError(t, f.auth.SignUp(ctx, "erin", "secret-password", "erin@example.com"))
+	dave, err := f.users.GetByUsername(ctx, "dave")
+	require.NoError(t, err)
+
+	user, err := f.account.Get(ctx, dave.ID)
+	require.NoError(t, err)
+	require.Equal(t, "dave@example.com", user.Email)
+	_, err = f.account.Get(ctx, uuid.New())
+	require.ErrorIs(t, err, ErrUserNotFound)
+
+	taken := "erin"
+	_, err = f.account.UpdateProfile(ctx, dave.ID, &ProfileUpdate{Username: &taken})
+	require.ErrorIs(t, err, ErrUsernameTaken)
+	username, email := "david", "david@example.com"
+	_, _, err = f.auth.SignIn(ctx, "dave", "old-password", &model.TokenParam{})
+	require.NoError(t, err)
+	sent := len(f.sender.messages)
+	_, err = f.account.UpdateProfile(ctx, dave.ID, &ProfileUpdate{Username: &username, Email: &email,
+		CurrentPassword: "wrong-password"})
+	require.ErrorIs(t, err, ErrWrongPassword)
+	user, err = f.account.UpdateProfile(ctx, dave.ID, &ProfileUpdate{Username: &username})
+	require.NoError(t, err)
+	require.Equal(t, "david", user.Username)
+	require.Equal(t, "dave@example.com", user.Email)
+	require.NotEmpty(t, f.sessions.sessions)
+	user, err = f.account.UpdateProfile(ctx, dave.ID, &ProfileUpdate{Email: &email, CurrentPassword: "old-password"})
+	require.NoError(t, err)
+	require.Equal(t, model.UserPending, user.Status)
+	require.Empty(t, f.sessions.sessions)
+	require.Len(t, f.sender.messages, sent+1)
+	require.Equal(t, email, f.sender.messages[sent].To)
+
+	_, _, err = f.auth.SignIn(ctx, "david", "old-password", &model.TokenParam{})
+	require.NoError(t, err)
+	require.ErrorIs(t, f.account.ChangePassword(ctx, dave.ID, "wrong-password", "new-password"), ErrWrongPassword)
+	require.ErrorIs(t, f.account.ChangePassword(ctx, dave.ID, "old-password", "david-123"), password.ErrWeakPassword)
+	require.NoError(t, f.account.ChangePassword(ctx, dave.ID, "old-password", "new-password"))
+	require.Empty(t, f.sessions.sessions)
+	_, _, err = f.auth.SignIn(ctx, "david", "new-password", &model.TokenParam{})
+	require.NoError(t, err)
+
+	require.ErrorIs(t, f.account.Delete(ctx, dave.ID, "old-password"), ErrWrongPassword)
+	require.NoError(t, f.account.Delete(ctx, dave.ID, "new-password"))
+	require.ErrorIs(t, f.account.Delete(ctx, dave.ID, "new-password"), ErrUserNotFound)
+}
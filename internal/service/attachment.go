@@ -0,0 +1,310 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/config"
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/repository"
+	"github.com/Entetry/gocompany/internal/storage"
+)
+
+// attachmentKeyPrefix blob store keys of attachment chunks start with it
+const attachmentKeyPrefix = "attachment/"
+
+var (
+	// ErrAttachmentNotFound company has no attachment with given id
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	// ErrAttachmentTooLarge declared attachment size is over limit
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+	// ErrInvalidAttachment attachment metadata is missing or invalid
+	ErrInvalidAttachment = errors.New("invalid attachment")
+	// ErrOffsetMismatch chunk doesn't start where upload stopped, client must ask for current offset
+	ErrOffsetMismatch = errors.New("chunk offset doesn't match upload offset")
+	// ErrChecksumMismatch chunk content doesn't match its checksum
+	ErrChecksumMismatch = errors.New("chunk checksum mismatch")
+	// ErrChunkTooLarge chunk is over chunk limit or beyond declared attachment size
+	ErrChunkTooLarge = errors.New("chunk is too large")
+	// ErrEmptyChunk chunk has no content
+	ErrEmptyChunk = errors.New("chunk is empty")
+	// ErrUploadIncomplete attachment can't be downloaded before all its chunks are uploaded
+	ErrUploadIncomplete = errors.New("attachment upload is incomplete")
+)
+
+// AttachmentFile attachment content assembled from its chunks
+type AttachmentFile struct {
+	*model.Attachment
+	Content io.ReadSeekCloser
+}
+
+// Attachment service of company attachments uploaded in resumable chunks
+type Attachment struct {
+	attachmentRepository repository.AttachmentRepository
+	blobStore            storage.BlobStore
+	maxSize              int64
+	maxChunk             int64
+}
+
+// NewAttachment creates new Attachment service, chunks are kept in the same blob store as logos
+func NewAttachment(attachmentRepository repository.AttachmentRepository, blobStore storage.BlobStore,
+	cfg *config.AttachmentConfig) *Attachment {
+	return &Attachment{attachmentRepository: attachmentRepository, blobStore: blobStore, maxSize: cfg.MaxSize,
+		maxChunk: cfg.MaxChunk}
+}
+
+// Create starts upload of attachment with declared size, incomplete upload expires after upload ttl
+func (a *Attachment) Create(ctx context.Context, companyID uuid.UUID, fileName, contentType string,
+	size int64) (*model.Attachment, error) {
+	if fileName == "" || size <= 0 {
+		return nil, fmt.Errorf("%w: file name and positive size are required", ErrInvalidAttachment)
+	}
+	if size > a.maxSize {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrAttachmentTooLarge, a.maxSize)
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	hashState, err := sha256.New().(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	attachment := &model.Attachment{ID: uuid.New(), CompanyID: companyID, FileName: path.Base(fileName),
+		ContentType: contentType, Size: size, HashState: hashState}
+	created, err := a.attachmentRepository.Create(ctx, attachment)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrCompanyNotFound
+	}
+	return attachment, nil
+}
+
+// Get returns company attachment
+func (a *Attachment) Get(ctx context.Context, companyID, id uuid.UUID) (*model.Attachment, error) {
+	attachment, err := a.attachmentRepository.Get(ctx, companyID, id)
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil {
+		return nil, ErrAttachmentNotFound
+	}
+	return attachment, nil
+}
+
+// GetAll returns all attachments of company including unfinished uploads
+func (a *Attachment) GetAll(ctx context.Context, companyID uuid.UUID) ([]*model.Attachment, error) {
+	return a.attachmentRepository.GetByCompanyID(ctx, companyID)
+}
+
+// AppendChunk verifies chunk against its sha256 checksum and appends it at offset, which must be current upload
+// offset. Chunk is stored under its own key, so a concurrent chunk for the same offset can't overwrite it.
+func (a *Attachment) AppendChunk(ctx context.Context, companyID, id uuid.UUID, offset int64, checksum []byte,
+	body io.Reader) (*model.Attachment, error) {
+	attachment, err := a.Get(ctx, companyID, id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != attachment.Offset {
+		return attachment, fmt.Errorf("%w: upload offset is %d", ErrOffsetMismatch, attachment.Offset)
+	}
+	limit := a.maxChunk
+	if remaining := attachment.Size - attachment.Offset; remaining < limit {
+		limit = remaining
+	}
+	// one extra byte tells chunk is over limit
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case int64(len(data)) > limit:
+		return nil, fmt.Errorf("%w: at most %d bytes expected", ErrChunkTooLarge, limit)
+	case len(data) == 0:
+		return nil, ErrEmptyChunk
+	}
+	sum := sha256.Sum256(data)
+	if !bytes.Equal(sum[:], checksum) {
+		return nil, ErrChecksumMismatch
+	}
+
+	next, err := continueHash(attachment, data)
+	if err != nil {
+		return nil, err
+	}
+	chunk := &model.AttachmentChunk{AttachmentID: id, Index: attachment.Chunks, Offset: offset,
+		Size: int64(len(data))}
+	chunk.StorageKey = path.Join(attachmentKeyPrefix, id.String(), strconv.Itoa(chunk.Index)+"-"+uuid.NewString())
+	err = a.blobStore.Put(ctx, chunk.StorageKey, bytes.NewReader(data), chunk.Size)
+	if err != nil {
+		log.Error(err)
+		return nil, fmt.Errorf(fileSaveError)
+	}
+	appended, err := a.attachmentRepository.AppendChunk(ctx, next, chunk)
+	if err != nil || !appended {
+		a.deleteChunk(ctx, chunk.StorageKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !appended {
+		return nil, ErrOffsetMismatch
+	}
+	return next, nil
+}
+
+// Open opens content of completely uploaded attachment, content reads chunk blobs one after another
+func (a *Attachment) Open(ctx context.Context, companyID, id uuid.UUID) (*AttachmentFile, error) {
+	attachment, err := a.Get(ctx, companyID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !attachment.Complete() {
+		return nil, ErrUploadIncomplete
+	}
+	chunks, err := a.attachmentRepository.GetChunks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &AttachmentFile{Attachment: attachment, Content: &chunkReader{ctx: ctx, blobStore: a.blobStore,
+		chunks: chunks, size: attachment.Size}}, nil
+}
+
+func (a *Attachment) deleteChunk(ctx context.Context, key string) {
+	err := a.blobStore.Delete(ctx, key)
+	if err != nil {
+		log.Errorf("cannot delete attachment chunk %s: %v", key, err)
+	}
+}
+
+// continueHash returns attachment state after data is appended, hash of previous chunks is restored from
+// its marshaled state instead of reading them again
+func continueHash(attachment *model.Attachment, data []byte) (*model.Attachment, error) {
+	hash := sha256.New()
+	err := hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(attachment.HashState)
+	if err != nil {
+		return nil, fmt.Errorf("cannot restore attachment hash: %v", err)
+	}
+	hash.Write(data)
+	next := *attachment
+	next.Offset += int64(len(data))
+	next.Chunks++
+	next.HashState, err = hash.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if next.Complete() {
+		completedAt := time.Now()
+		next.Checksum = hex.EncodeToString(hash.Sum(nil))
+		next.CompletedAt = &completedAt
+	}
+	return &next, nil
+}
+
+// chunkReader reads and seeks over attachment chunks, blob of chunk is opened when read reaches it
+type chunkReader struct {
+	ctx       context.Context
+	blobStore storage.BlobStore
+	chunks    []*model.AttachmentChunk
+	size      int64
+	offset    int64
+	// current open chunk blob positioned at offset, nil after seek
+	current    io.ReadCloser
+	currentEnd int64
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if c.offset >= c.size {
+		return 0, io.EOF
+	}
+	if c.current == nil {
+		err := c.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+	if remaining := c.currentEnd - c.offset; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := c.current.Read(p)
+	c.offset += int64(n)
+	if c.offset == c.currentEnd {
+		return n, c.closeCurrent()
+	}
+	if errors.Is(err, io.EOF) {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (c *chunkReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += c.offset
+	case io.SeekEnd:
+		offset += c.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative offset %d", offset)
+	}
+	if offset != c.offset {
+		err := c.closeCurrent()
+		if err != nil {
+			return 0, err
+		}
+		c.offset = offset
+	}
+	return offset, nil
+}
+
+func (c *chunkReader) Close() error {
+	return c.closeCurrent()
+}
+
+// open opens chunk holding offset and skips to offset within it
+func (c *chunkReader) open() error {
+	i := sort.Search(len(c.chunks), func(i int) bool {
+		return c.chunks[i].Offset+c.chunks[i].Size > c.offset
+	})
+	if i == len(c.chunks) || c.chunks[i].Offset > c.offset {
+		return fmt.Errorf("attachment has no chunk at offset %d", c.offset)
+	}
+	chunk := c.chunks[i]
+	blob, err := c.blobStore.Get(c.ctx, chunk.StorageKey)
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(io.Discard, blob, c.offset-chunk.Offset)
+	if err != nil {
+		_ = blob.Close()
+		return err
+	}
+	c.current, c.currentEnd = blob, chunk.Offset+chunk.Size
+	return nil
+}
+
+func (c *chunkReader) closeCurrent() error {
+	if c.current == nil {
+		return nil
+	}
+	err := c.current.Close()
+	c.current = nil
+	return err
+}
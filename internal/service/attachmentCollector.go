@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/config"
+	"github.com/Entetry/gocompany/internal/repository"
+	"github.com/Entetry/gocompany/internal/storage"
+)
+
+// AttachmentCollection result of attachment garbage collection
+type AttachmentCollection struct {
+	// Expired count of deleted incomplete uploads
+	Expired int64
+	// Scanned count of listed chunk blobs
+	Scanned int
+	// Orphans deleted keys of chunk blobs no attachment references
+	Orphans []string
+}
+
+// AttachmentCollector deletes expired uploads and chunk blobs of deleted attachments
+type AttachmentCollector struct {
+	attachmentRepository repository.AttachmentRepository
+	blobStore            storage.BlobStore
+	uploadTTL            time.Duration
+	grace                time.Duration
+}
+
+// NewAttachmentCollector creates new AttachmentCollector
+func NewAttachmentCollector(attachmentRepository repository.AttachmentRepository, blobStore storage.BlobStore,
+	cfg *config.AttachmentConfig) *AttachmentCollector {
+	return &AttachmentCollector{attachmentRepository: attachmentRepository, blobStore: blobStore,
+		uploadTTL: cfg.UploadTTL, grace: cfg.GCGrace}
+}
+
+// Run collects garbage every interval until ctx is canceled
+func (a *AttachmentCollector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			collection, err := a.Collect(ctx)
+			if err != nil {
+				log.Errorf("attachment garbage collection failed: %v", err)
+				continue
+			}
+			collection.Log()
+		}
+	}
+}
+
+// Collect deletes incomplete uploads older than upload ttl, then deletes chunk blobs no attachment references,
+// which are left by expired uploads, deleted companies and failed appends. Orphans younger than grace period
+// are kept.
+func (a *AttachmentCollector) Collect(ctx context.Context) (*AttachmentCollection, error) {
+	collection := new(AttachmentCollection)
+	var err error
+	if a.uploadTTL > 0 {
+		collection.Expired, err = a.attachmentRepository.DeleteIncomplete(ctx, time.Now().Add(-a.uploadTTL))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	keys, err := a.attachmentRepository.GetChunkKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		referenced[key] = true
+	}
+	err = a.blobStore.List(ctx, attachmentKeyPrefix, func(blob storage.BlobInfo) error {
+		collection.Scanned++
+		if referenced[blob.Key] || time.Since(blob.ModTime) < a.grace {
+			return nil
+		}
+		deleteErr := a.blobStore.Delete(ctx, blob.Key)
+		if deleteErr != nil {
+			return deleteErr
+		}
+		collection.Orphans = append(collection.Orphans, blob.Key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// Log writes collection summary to log
+func (c *AttachmentCollection) Log() {
+	log.Infof("attachment garbage collection deleted %d expired uploads, scanned %d blobs and deleted %d orphans",
+		c.Expired, c.Scanned, len(c.Orphans))
+}
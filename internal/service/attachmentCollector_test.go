@@ -0,0 +1,64 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/config"
+	"github.com/Entetry/gocompany/internal/storage"
+)
+
+func TestAttachmentCollector_Collect(t *testing.T) {
+	t.Log("Given the need to test expired uploads and chunk blobs of deleted attachments are collected.")
+	ctx := context.Background()
+	companyID, deletedID := uuid.New(), uuid.New()
+	attachmentService, attachmentRepository, blobStore := newAttachmentService(t, companyID)
+	attachmentRepository.companies[deletedID] = true
+	content := []byte("contract")
+
+	upload := func(companyID uuid.UUID, size int64) string {
+		attachment, err := attachmentService.Create(ctx, companyID, "contract.txt", "text/plain", size)
+		require.NoError(t, err)
+		_, err = attachmentService.AppendChunk(ctx, companyID, attachment.ID, 0, checksum(content),
+			bytes.NewReader(content))
+		require.NoError(t, err)
+		return attachmentRepository.chunks[attachment.ID][0].StorageKey
+	}
+	complete := upload(companyID, int64(len(content)))
+	incomplete := upload(companyID, 2*int64(len(content)))
+	expired := upload(companyID, 2*int64(len(content)))
+	deleted := upload(deletedID, int64(len(content)))
+	for _, attachment := range attachmentRepository.attachments {
+		if attachmentRepository.chunks[attachment.ID][0].StorageKey == expired {
+			attachment.CreatedAt = time.Now().Add(-2 * time.Hour)
+		}
+	}
+	attachmentRepository.deleteCompany(deletedID)
+
+	collector := NewAttachmentCollector(attachmentRepository, blobStore, &config.AttachmentConfig{
+		UploadTTL: time.Hour, GCGrace: time.Hour})
+	collection, err := collector.Collect(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), collection.Expired)
+	require.Empty(t, collection.Orphans, "young orphans are kept")
+
+	collector.grace = 0
+	collection, err = collector.Collect(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 4, collection.Scanned)
+	require.ElementsMatch(t, []string{expired, deleted}, collection.Orphans)
+	for _, key := range []string{expired, deleted} {
+		_, err = blobStore.Get(ctx, key)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	}
+	for _, key := range []string{complete, incomplete} {
+		blob, err := blobStore.Get(ctx, key)
+		require.NoError(t, err)
+		require.NoError(t, blob.Close())
+	}
+}
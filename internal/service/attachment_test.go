@@ -0,0 +1,187 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/config"
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/storage"
+)
+
+// fakeAttachmentRepository keeps companies, attachments and their chunks in memory
+type fakeAttachmentRepository struct {
+	companies   map[uuid.UUID]bool
+	attachments map[uuid.UUID]*model.Attachment
+	chunks      map[uuid.UUID][]*model.AttachmentChunk
+}
+
+func (f *fakeAttachmentRepository) Create(_ context.Context, attachment *model.Attachment) (bool, error) {
+	if !f.companies[attachment.CompanyID] {
+		return false, nil
+	}
+	stored := *attachment
+	stored.CreatedAt = time.Now()
+	f.attachments[attachment.ID] = &stored
+	return true, nil
+}
+
+func (f *fakeAttachmentRepository) Get(_ context.Context, companyID, id uuid.UUID) (*model.Attachment, error) {
+	attachment, ok := f.attachments[id]
+	if !ok || attachment.CompanyID != companyID {
+		return nil, nil
+	}
+	copied := *attachment
+	return &copied, nil
+}
+
+func (f *fakeAttachmentRepository) GetByCompanyID(_ context.Context, companyID uuid.UUID) ([]*model.Attachment,
+	error) {
+	var attachments []*model.Attachment
+	for _, attachment := range f.attachments {
+		if attachment.CompanyID == companyID {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments, nil
+}
+
+func (f *fakeAttachmentRepository) AppendChunk(_ context.Context, attachment *model.Attachment,
+	chunk *model.AttachmentChunk) (bool, error) {
+	if f.attachments[attachment.ID].Offset != chunk.Offset {
+		return false, nil
+	}
+	stored := *attachment
+	f.attachments[attachment.ID] = &stored
+	f.chunks[attachment.ID] = append(f.chunks[attachment.ID], chunk)
+	return true, nil
+}
+
+func (f *fakeAttachmentRepository) GetChunks(_ context.Context, attachmentID uuid.UUID) ([]*model.AttachmentChunk,
+	error) {
+	return f.chunks[attachmentID], nil
+}
+
+func (f *fakeAttachmentRepository) GetChunkKeys(_ context.Context) ([]string, error) {
+	var keys []string
+	for _, chunks := range f.chunks {
+		for _, chunk := range chunks {
+			keys = append(keys, chunk.StorageKey)
+		}
+	}
+	return keys, nil
+}
+
+func (f *fakeAttachmentRepository) DeleteIncomplete(_ context.Context, createdBefore time.Time) (int64, error) {
+	var deleted int64
+	for id, attachment := range f.attachments {
+		if !attachment.Complete() && attachment.CreatedAt.Before(createdBefore) {
+			f.delete(id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// deleteCompany deletes company with its attachments like foreign key cascade does
+func (f *fakeAttachmentRepository) deleteCompany(companyID uuid.UUID) {
+	delete(f.companies, companyID)
+	for id, attachment := range f.attachments {
+		if attachment.CompanyID == companyID {
+			f.delete(id)
+		}
+	}
+}
+
+func (f *fakeAttachmentRepository) delete(id uuid.UUID) {
+	delete(f.attachments, id)
+	delete(f.chunks, id)
+}
+
+func newAttachmentService(t *testing.T, companyID uuid.UUID) (*Attachment, *fakeAttachmentRepository,
+	storage.BlobStore) {
+	attachmentRepository := &fakeAttachmentRepository{companies: map[uuid.UUID]bool{companyID: true},
+		attachments: make(map[uuid.UUID]*model.Attachment), chunks: make(map[uuid.UUID][]*model.AttachmentChunk)}
+	blobStore := storage.NewLocal(t.TempDir())
+	return NewAttachment(attachmentRepository, blobStore, &config.AttachmentConfig{MaxSize: 100, MaxChunk: 10}),
+		attachmentRepository, blobStore
+}
+
+func checksum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+func TestAttachment_ChunkedUpload(t *testing.T) {
+	t.Log("Given the need to test attachment is uploaded in verified chunks, resumed and downloaded by ranges.")
+	ctx := context.Background()
+	companyID := uuid.New()
+	attachmentService, _, _ := newAttachmentService(t, companyID)
+	content := []byte("contract signed by both parties")
+
+	_, err := attachmentService.Create(ctx, companyID, "contract.txt", "text/plain", 101)
+	require.ErrorIs(t, err, ErrAttachmentTooLarge)
+	_, err = attachmentService.Create(ctx, uuid.New(), "contract.txt", "text/plain", int64(len(content)))
+	require.ErrorIs(t, err, ErrCompanyNotFound)
+	attachment, err := attachmentService.Create(ctx, companyID, "../contract.txt", "text/plain", int64(len(content)))
+	require.NoError(t, err)
+	require.Equal(t, "contract.txt", attachment.FileName)
+
+	_, err = attachmentService.AppendChunk(ctx, companyID, attachment.ID, 0, checksum(content), bytes.NewReader(content))
+	require.ErrorIs(t, err, ErrChunkTooLarge)
+	_, err = attachmentService.AppendChunk(ctx, companyID, attachment.ID, 0, checksum(content[:5]),
+		bytes.NewReader(content[:10]))
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+
+	for offset := 0; offset < len(content); offset += 10 {
+		chunk := content[offset:minInt(offset+10, len(content))]
+		_, err = attachmentService.Open(ctx, companyID, attachment.ID)
+		require.ErrorIs(t, err, ErrUploadIncomplete)
+		attachment, err = attachmentService.AppendChunk(ctx, companyID, attachment.ID, int64(offset),
+			checksum(chunk), bytes.NewReader(chunk))
+		require.NoError(t, err)
+		require.Equal(t, int64(offset+len(chunk)), attachment.Offset)
+
+		// resent chunk, e.g. after lost response, is rejected with offset to resume from
+		current, err := attachmentService.AppendChunk(ctx, companyID, attachment.ID, int64(offset),
+			checksum(chunk), bytes.NewReader(chunk))
+		require.ErrorIs(t, err, ErrOffsetMismatch)
+		require.Equal(t, attachment.Offset, current.Offset)
+	}
+	require.True(t, attachment.Complete())
+	require.Equal(t, hex.EncodeToString(checksum(content)), attachment.Checksum)
+
+	file, err := attachmentService.Open(ctx, companyID, attachment.ID)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, file.Content.Close()) }()
+	read, err := io.ReadAll(file.Content)
+	require.NoError(t, err)
+	require.Equal(t, content, read)
+
+	request := httptest.NewRequest(http.MethodGet, "/content", http.NoBody)
+	request.Header.Set("Range", "bytes=8-22")
+	recorder := httptest.NewRecorder()
+	http.ServeContent(recorder, request, "", time.Time{}, file.Content)
+	require.Equal(t, http.StatusPartialContent, recorder.Code)
+	require.Equal(t, content[8:23], recorder.Body.Bytes())
+
+	_, err = attachmentService.Get(ctx, uuid.New(), attachment.ID)
+	require.ErrorIs(t, err, ErrAttachmentNotFound)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -2,13 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
 
 	"github.com/Entetry/gocompany/internal/config"
 	"github.com/Entetry/gocompany/internal/model"
@@ -21,27 +21,39 @@ const (
 	userAlreadyExist      = "user already exists"
 )
 
+// ErrEmailNotVerified user has to verify email before signing in
+var ErrEmailNotVerified = errors.New("email is not verified")
+
 // AuthService service interface
 type AuthService interface {
 	SignIn(ctx context.Context, username, password string, tokenParam *model.TokenParam) (refreshToken, accessToken string, err error)
 	SignUp(ctx context.Context, username, password, email string) error
 	RefreshTokens(ctx context.Context, refreshToken string, tokenParam *model.TokenParam) (newRefreshToken, accessToken string, err error)
 	Logout(ctx context.Context, refreshToken string) (err error)
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
 }
 
 // Auth service struct
 type Auth struct {
-	userService    *User
-	refreshSession *RefreshSession
-	cfg            *config.JwtConfig
+	userService       *User
+	refreshSession    *RefreshSession
+	emailVerification *EmailVerification
+	passwordReset     *PasswordReset
+	cfg               *config.JwtConfig
 }
 
 // NewAuthService creates new Auth service
-func NewAuthService(userService *User, refreshSession *RefreshSession, cfg *config.JwtConfig) *Auth {
+func NewAuthService(userService *User, refreshSession *RefreshSession, emailVerification *EmailVerification,
+	passwordReset *PasswordReset, cfg *config.JwtConfig) *Auth {
 	return &Auth{
-		userService:    userService,
-		refreshSession: refreshSession,
-		cfg:            cfg}
+		userService:       userService,
+		refreshSession:    refreshSession,
+		emailVerification: emailVerification,
+		passwordReset:     passwordReset,
+		cfg:               cfg}
 }
 
 // SignIn sign in user and return tokens
@@ -50,10 +62,13 @@ func (a *Auth) SignIn(ctx context.Context, username, password string, tokenParam
 	if err != nil {
 		return "", "", fmt.Errorf(wrongPassword)
 	}
+	if user.Status == model.UserPending && a.emailVerification.Required() {
+		return "", "", ErrEmailNotVerified
+	}
 	return a.generateTokens(ctx, user.ID.String(), tokenParam)
 }
 
-// SignUp  sign up new user
+// SignUp  sign up new user, user stays pending until email is verified
 func (a *Auth) SignUp(ctx context.Context, username, password, email string) error {
 	user, err := a.userService.GetByUsername(ctx, username)
 	if err != nil {
@@ -63,10 +78,15 @@ func (a *Auth) SignUp(ctx context.Context, username, password, email string) err
 		return fmt.Errorf(userAlreadyExist)
 	}
 
-	_, err = a.userService.Create(ctx, username, password, email)
+	userID, err := a.userService.Create(ctx, username, password, email, model.UserPending)
 	if err != nil {
 		return err
 	}
+	// user can request another email, so failed sending doesn't fail sign up
+	err = a.emailVerification.Send(ctx, &model.User{ID: userID, Username: username, Email: email})
+	if err != nil {
+		log.Errorf("cannot send verification email to user %s: %v", userID, err)
+	}
 	return nil
 }
 
@@ -105,6 +125,26 @@ func (a *Auth) Logout(ctx context.Context, refreshToken string) (err error) {
 	return nil
 }
 
+// VerifyEmail activates user of verification token
+func (a *Auth) VerifyEmail(ctx context.Context, token string) error {
+	return a.emailVerification.Verify(ctx, token)
+}
+
+// ResendVerification sends verification email again to pending users with email
+func (a *Auth) ResendVerification(ctx context.Context, email string) error {
+	return a.emailVerification.Resend(ctx, email)
+}
+
+// ForgotPassword sends password reset email to users with email
+func (a *Auth) ForgotPassword(ctx context.Context, email string) error {
+	return a.passwordReset.Forgot(ctx, email)
+}
+
+// ResetPassword sets new password of reset token user
+func (a *Auth) ResetPassword(ctx context.Context, token, password string) error {
+	return a.passwordReset.Reset(ctx, token, password)
+}
+
 func (a *Auth) checkFingerprint(session *model.RefreshSession, tokenParam *model.TokenParam) bool {
 	return tokenParam.IP == session.TokenParam.IP ||
 		tokenParam.UserAgent == session.UserAgent ||
@@ -116,7 +156,11 @@ func (a *Auth) attemptLogin(ctx context.Context, username, password string) (*mo
 	if err != nil {
 		return nil, err
 	}
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	if user == nil {
+		a.userService.CheckMissingPassword(password)
+		return nil, fmt.Errorf("user %s not found", username)
+	}
+	err = a.userService.CheckPassword(ctx, user, password)
 	if err != nil {
 		return nil, err
 	}
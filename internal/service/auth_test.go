@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/password"
+)
+
+func TestAuth_SignUpPasswordPolicy(t *testing.T) {
+	t.Log("Given the need to test sign up is refused with weak password and reasons are described.")
+	f := newAuthFixture()
+	ctx := context.Background()
+
+	err := f.auth.SignUp(ctx, "grace", "grace", "grace@example.com")
+	require.ErrorIs(t, err, password.ErrWeakPassword)
+	require.ErrorContains(t, err, "at least 8 characters")
+	require.ErrorContains(t, err, "must not contain username or email")
+	user, err := f.users.GetByUsername(ctx, "grace")
+	require.NoError(t, err)
+	require.Nil(t, user)
+	require.Empty(t, f.sender.messages)
+}
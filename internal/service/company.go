@@ -1,29 +1,55 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/Entetry/gocompany/internal/repository"
 	"io"
 	"mime/multipart"
-	"os"
-	"path/filepath"
+	"path"
+	"sort"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/Entetry/gocompany/internal/cache"
+	"github.com/Entetry/gocompany/internal/config"
 	"github.com/Entetry/gocompany/internal/event"
+	"github.com/Entetry/gocompany/internal/imaging"
 	"github.com/Entetry/gocompany/internal/model"
 	"github.com/Entetry/gocompany/internal/producer"
+	"github.com/Entetry/gocompany/internal/storage"
+)
+
+var (
+	// ErrCompanyNotFound logo or attachment can't be added to company which doesn't exist
+	ErrCompanyNotFound = errors.New("company not found")
+	// ErrLogoNotFound company has no logo
+	ErrLogoNotFound = errors.New("company has no logo")
+	// ErrLogoVersionNotFound company has no logo version with given number
+	ErrLogoVersionNotFound = errors.New("logo version not found")
 )
 
 const (
 	companyAlreadyHasALogoErr = "company already has a logo"
 	fileSaveError             = "file save error"
-	imageExt                  = ".jpeg"
+	// logoKeyPrefix blob store keys of logos and their renditions start with it
+	logoKeyPrefix = "logo/"
 )
 
+// LogoFile company logo content, ETag is quoted sha256 of content so it changes with every replaced logo
+type LogoFile struct {
+	Content     *bytes.Reader
+	ContentType string
+	ETag        string
+}
+
 type CompanyService interface {
 	GetAll(ctx context.Context) ([]*model.Company, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Company, error)
@@ -31,23 +57,39 @@ type CompanyService interface {
 	Update(ctx context.Context, company *model.Company) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	AddLogo(ctx context.Context, companyID string, file *multipart.FileHeader) error
-	GetLogo(ctx context.Context, companyID uuid.UUID) (string, error)
+	GetLogo(ctx context.Context, companyID uuid.UUID, size int) (*LogoFile, error)
+	ReplaceLogo(ctx context.Context, companyID uuid.UUID, file *multipart.FileHeader) error
+	DeleteLogo(ctx context.Context, companyID uuid.UUID) error
+	GetLogoVersions(ctx context.Context, companyID uuid.UUID) ([]*model.Logo, error)
+	GetLogoVersion(ctx context.Context, companyID uuid.UUID, version, size int) (*LogoFile, error)
+	RollbackLogo(ctx context.Context, companyID uuid.UUID, version int) error
 }
 
 // Company service company struct
 type Company struct {
-	companyRepository repository.CompanyRepository
-	logoRepository    repository.LogoRepository
-	cache             cache.Cache
-	producer          producer.Company
+	companyRepository  repository.CompanyRepository
+	logoRepository     repository.LogoRepository
+	blobStore          storage.BlobStore
+	logoLimits         imaging.Limits
+	logoRenditions     []int
+	cache              cache.Cache
+	producer           producer.Company
+	cacheFillBroadcast bool
 }
 
-// NewCompany creates new Company service
+// NewCompany creates new Company service, with cacheFillBroadcast companies read from db are published
+// to fill caches of all replicas, otherwise only local cache is filled
 func NewCompany(
 	companyRepository repository.CompanyRepository, logoRepository repository.LogoRepository,
-	localCache cache.Cache, redisProducer producer.Company) *Company {
+	blobStore storage.BlobStore, logoCfg *config.LogoConfig, localCache cache.Cache,
+	companyProducer producer.Company, cacheFillBroadcast bool) *Company {
+	logoLimits := imaging.Limits{MaxBytes: logoCfg.MaxBytes, MaxWidth: logoCfg.MaxWidth, MaxHeight: logoCfg.MaxHeight}
+	logoRenditions := append([]int(nil), logoCfg.Renditions...)
+	sort.Ints(logoRenditions)
 	return &Company{
-		companyRepository: companyRepository, logoRepository: logoRepository, cache: localCache, producer: redisProducer}
+		companyRepository: companyRepository, logoRepository: logoRepository, blobStore: blobStore,
+		logoLimits: logoLimits, logoRenditions: logoRenditions, cache: localCache, producer: companyProducer,
+		cacheFillBroadcast: cacheFillBroadcast}
 }
 
 // GetAll return all companies
@@ -64,28 +106,39 @@ func (c *Company) GetByID(ctx context.Context, id uuid.UUID) (*model.Company, er
 	if company != nil {
 		return company, nil
 	}
+	if c.cache.IsMissing(id) {
+		return nil, echo.ErrNotFound
+	}
 	company, err = c.companyRepository.GetOne(ctx, id)
-	if company != nil {
-		redisErr := c.producer.Produce(ctx, company.ID, event.UPDATE, company.Name)
-		if redisErr != nil {
-			log.Error(err)
-		}
+	if errors.Is(err, echo.ErrNotFound) {
+		c.cache.MarkMissing(id)
+	}
+	if company == nil {
+		return company, err
+	}
+	if !c.cacheFillBroadcast {
+		c.cache.Update(company.ID, company.Name)
+		return company, err
+	}
+	produceErr := c.producer.Produce(ctx, &event.CompanyUpdated{CompanyID: company.ID, Name: company.Name})
+	if produceErr != nil {
+		log.Error(produceErr)
 	}
 
 	return company, err
 }
 
-// Create  company
+// Create  company, CREATE event is published from outbox
 func (c *Company) Create(ctx context.Context, company *model.Company) (uuid.UUID, error) {
 	return c.companyRepository.Create(ctx, company)
 }
 
-// Update update company
+// Update update company, UPDATE event is published from outbox
 func (c *Company) Update(ctx context.Context, company *model.Company) error {
 	return c.companyRepository.Update(ctx, company)
 }
 
-// Delete delete company
+// Delete delete company, DELETE event is published from outbox
 func (c *Company) Delete(ctx context.Context, id uuid.UUID) error {
 	company, err := c.cache.Read(id)
 	if err != nil {
@@ -97,7 +150,7 @@ func (c *Company) Delete(ctx context.Context, id uuid.UUID) error {
 	return c.companyRepository.Delete(ctx, id)
 }
 
-// AddLogo add logo to a company( fails if company already has a logo)
+// AddLogo add logo to a company( fails if company already has a logo), content must be an image within limits
 func (c *Company) AddLogo(ctx context.Context, companyID string, file *multipart.FileHeader) error {
 	id, err := uuid.Parse(companyID)
 	if err != nil {
@@ -110,65 +163,251 @@ func (c *Company) AddLogo(ctx context.Context, companyID string, file *multipart
 	if logo != nil {
 		return fmt.Errorf(companyAlreadyHasALogoErr)
 	}
-	imageURI := c.buildFileURI(companyID)
-	err = c.saveFile(imageURI, file)
+	err = c.requireCompany(ctx, id)
 	if err != nil {
-		return fmt.Errorf(fileSaveError)
+		return err
 	}
+	img, storageKey, err := c.storeLogo(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	// blob may be shared with other logos, if it's not it's collected as orphan
+	return c.logoRepository.Create(ctx, id, storageKey, img.ContentType)
+}
+
+// ReplaceLogo makes uploaded logo current company logo, previous logo is kept as older version
+func (c *Company) ReplaceLogo(ctx context.Context, companyID uuid.UUID, file *multipart.FileHeader) error {
+	err := c.requireCompany(ctx, companyID)
+	if err != nil {
+		return err
+	}
+	img, storageKey, err := c.storeLogo(ctx, file)
+	if err != nil {
+		return err
+	}
+	return c.logoRepository.Replace(ctx, companyID, storageKey, img.ContentType)
+}
 
-	err = c.logoRepository.Create(ctx, id, imageURI)
+// DeleteLogo leaves company without current logo, deleted logo is kept as older version
+func (c *Company) DeleteLogo(ctx context.Context, companyID uuid.UUID) error {
+	deleted, err := c.logoRepository.Delete(ctx, companyID)
 	if err != nil {
 		return err
 	}
+	if deleted == nil {
+		return ErrLogoNotFound
+	}
 	return nil
 }
 
-// GetLogo Get company logo
-func (c *Company) GetLogo(ctx context.Context, companyID uuid.UUID) (string, error) {
+// GetLogoVersions returns all logo versions of company, latest first
+func (c *Company) GetLogoVersions(ctx context.Context, companyID uuid.UUID) ([]*model.Logo, error) {
+	return c.logoRepository.GetVersions(ctx, companyID)
+}
+
+// GetLogoVersion reads company logo version the same way GetLogo reads current logo
+func (c *Company) GetLogoVersion(ctx context.Context, companyID uuid.UUID, version, size int) (*LogoFile, error) {
+	logo, err := c.logoRepository.GetVersion(ctx, companyID, version)
+	if err != nil {
+		return nil, err
+	}
+	if logo == nil {
+		return nil, ErrLogoVersionNotFound
+	}
+	return c.logoFile(ctx, logo, size)
+}
+
+// RollbackLogo makes company logo version current again
+func (c *Company) RollbackLogo(ctx context.Context, companyID uuid.UUID, version int) error {
+	logo, err := c.logoRepository.Rollback(ctx, companyID, version)
+	if err != nil {
+		return err
+	}
+	if logo == nil {
+		return ErrLogoVersionNotFound
+	}
+	return nil
+}
+
+// GetLogo reads current company logo, returns ErrLogoNotFound if company has no logo. Positive size selects
+// the nearest rendition, renditions of raster logos are generated on first request and kept in blob store.
+func (c *Company) GetLogo(ctx context.Context, companyID uuid.UUID, size int) (*LogoFile, error) {
 	logo, err := c.logoRepository.GetByCompanyID(ctx, companyID)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if logo == nil {
+		return nil, ErrLogoNotFound
 	}
-	return logo.Image, nil
+	return c.logoFile(ctx, logo, size)
 }
 
-func (c *Company) buildFileURI(companyID string) string {
-	wd, _ := os.Getwd()
-	basepath := filepath.Join(wd, "data", "company")
-	_ = os.MkdirAll(basepath, os.ModePerm)
-	fileURI := filepath.Join(basepath, companyID)
-	return fmt.Sprintf("%s%s", fileURI, imageExt)
+func (c *Company) logoFile(ctx context.Context, logo *model.Logo, size int) (*LogoFile, error) {
+	rendition := c.nearestRendition(size)
+	if rendition == 0 || !imaging.Resizable(logo.ContentType) {
+		file, err := c.openLogo(ctx, logo.StorageKey, logo.ContentType)
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Errorf("logo %s has no blob %s", logo.ID, logo.StorageKey)
+			return nil, ErrLogoNotFound
+		}
+		return file, err
+	}
+
+	contentType := imaging.RenditionType(logo.ContentType)
+	key := buildRenditionKey(logo.StorageKey, rendition, contentType)
+	file, err := c.openLogo(ctx, key, contentType)
+	if !errors.Is(err, storage.ErrNotFound) {
+		return file, err
+	}
+	data, err := c.renderLogo(ctx, logo, rendition, key, contentType)
+	if errors.Is(err, storage.ErrNotFound) {
+		log.Errorf("logo %s has no blob %s", logo.ID, logo.StorageKey)
+		return nil, ErrLogoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newLogoFile(data, contentType), nil
 }
 
-func (c *Company) saveFile(fileName string, file *multipart.FileHeader) error {
-	src, err := file.Open()
+// openLogo reads logo blob whole, logos are small enough and hash of content is needed before serving it
+func (c *Company) openLogo(ctx context.Context, key, contentType string) (*LogoFile, error) {
+	content, err := c.blobStore.Get(ctx, key)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
-		if srcError := src.Close(); srcError != nil {
-			log.Printf("Error closing file: %s\n", srcError)
+		if closeErr := content.Close(); closeErr != nil {
+			log.Error(closeErr)
 		}
 	}()
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	return newLogoFile(data, contentType), nil
+}
+
+func newLogoFile(data []byte, contentType string) *LogoFile {
+	sum := sha256.Sum256(data)
+	return &LogoFile{Content: bytes.NewReader(data), ContentType: contentType,
+		ETag: `"` + hex.EncodeToString(sum[:]) + `"`}
+}
 
-	dst, err := os.Create(filepath.Clean(fileName))
+// renderLogo resizes original logo and stores the rendition, failing to store it only costs another resize
+func (c *Company) renderLogo(ctx context.Context, logo *model.Logo, rendition int, key, contentType string) (
+	[]byte, error) {
+	original, err := c.blobStore.Get(ctx, logo.StorageKey)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
-		if dstError := dst.Close(); dstError != nil {
-			log.Printf("Error closing file: %s\n", dstError)
+		if closeErr := original.Close(); closeErr != nil {
+			log.Error(closeErr)
 		}
 	}()
+	data, err := io.ReadAll(original)
+	if err != nil {
+		return nil, err
+	}
+	img, err := imaging.Decode(&imaging.Image{Data: data, ContentType: logo.ContentType})
+	if err != nil {
+		return nil, err
+	}
+	data, err = imaging.Encode(imaging.Resize(img, rendition), contentType)
+	if err != nil {
+		return nil, err
+	}
+	err = c.blobStore.Put(ctx, key, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		log.Errorf("cannot store logo rendition %s: %v", key, err)
+	}
+	return data, nil
+}
 
-	if _, err = io.Copy(dst, src); err != nil {
-		return err
+// nearestRendition returns configured rendition closest to size, the larger one on a tie, 0 for original
+func (c *Company) nearestRendition(size int) int {
+	if size <= 0 {
+		return 0
+	}
+	nearest := 0
+	for _, rendition := range c.logoRenditions {
+		if nearest == 0 || abs(rendition-size) <= abs(nearest-size) {
+			nearest = rendition
+		}
 	}
+	return nearest
+}
 
-	err = dst.Sync()
+// storeLogo validates uploaded logo and writes it to blob store under its content hash. Blob is written even
+// if it exists, fresh modification time keeps garbage collection off it until it's referenced.
+func (c *Company) storeLogo(ctx context.Context, file *multipart.FileHeader) (*imaging.Image, string, error) {
+	data, err := c.readFile(file)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
+	img, err := imaging.Validate(data, c.logoLimits)
+	if err != nil {
+		return nil, "", err
+	}
+	storageKey := buildLogoKey(img.Data, img.ContentType)
+	err = c.blobStore.Put(ctx, storageKey, bytes.NewReader(img.Data), int64(len(img.Data)))
+	if err != nil {
+		log.Error(err)
+		return nil, "", fmt.Errorf(fileSaveError)
+	}
+	return img, storageKey, nil
+}
 
-	return nil
+// requireCompany returns ErrCompanyNotFound if company doesn't exist, logo isn't stored for it then
+func (c *Company) requireCompany(ctx context.Context, id uuid.UUID) error {
+	_, err := c.companyRepository.GetOne(ctx, id)
+	if errors.Is(err, echo.ErrNotFound) {
+		return ErrCompanyNotFound
+	}
+	return err
+}
+
+// buildLogoKey logo blob is addressed by sha256 of its content, so identical logos are stored once
+func buildLogoKey(data []byte, contentType string) string {
+	sum := sha256.Sum256(data)
+	return path.Join(logoKeyPrefix, hex.EncodeToString(sum[:])+imaging.Extension(contentType))
+}
+
+// buildRenditionKey rendition is kept next to original logo blob
+func buildRenditionKey(storageKey string, size int, contentType string) string {
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(storageKey, path.Ext(storageKey)), size,
+		imaging.Extension(contentType))
+}
+
+// readFile reads uploaded file, files over size limit are rejected without reading them whole
+func (c *Company) readFile(file *multipart.FileHeader) ([]byte, error) {
+	maxBytes := c.logoLimits.MaxBytes
+	if maxBytes > 0 && file.Size > maxBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", imaging.ErrTooLarge, maxBytes)
+	}
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if srcError := src.Close(); srcError != nil {
+			log.Printf("Error closing file: %s\n", srcError)
+		}
+	}()
+
+	var reader io.Reader = src
+	if maxBytes > 0 {
+		// one extra byte tells file grew over limit
+		reader = io.LimitReader(src, maxBytes+1)
+	}
+	return io.ReadAll(reader)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
 }
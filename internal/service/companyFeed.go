@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/bus"
+	"github.com/Entetry/gocompany/internal/event"
+)
+
+const feedReplayPage = 100
+
+// ErrInvalidEventID last event id is not a company topic position
+var ErrInvalidEventID = errors.New("invalid last event id")
+
+// FeedEvent company event as it is sent to feed subscribers
+type FeedEvent struct {
+	// ID position of event in company topic, clients resume after it
+	ID        string
+	Type      string
+	CompanyID uuid.UUID
+	Data      json.RawMessage
+
+	position bus.Position
+}
+
+// FeedFilter reports whether subscriber wants event
+type FeedFilter func(e *FeedEvent) bool
+
+// FeedSubscription company events of single feed subscriber
+type FeedSubscription struct {
+	events chan *FeedEvent
+	filter FeedFilter
+	// last replayed position, live events up to it were already sent
+	last *bus.Position
+}
+
+// Events returns live events, channel is closed when subscriber falls behind by more than feed buffer
+func (s *FeedSubscription) Events() <-chan *FeedEvent {
+	return s.events
+}
+
+// Replayed reports whether event was already sent by Replay
+func (s *FeedSubscription) Replayed(e *FeedEvent) bool {
+	return s.last != nil && !e.position.After(*s.last)
+}
+
+// CompanyFeed fans consumed company events out to connected clients
+type CompanyFeed struct {
+	replayer    bus.Replayer
+	buffer      int
+	mu          sync.Mutex
+	subscribers map[*FeedSubscription]struct{}
+}
+
+// NewCompanyFeed creates company feed, every subscriber may lag behind by buffer events
+func NewCompanyFeed(replayer bus.Replayer, buffer int) *CompanyFeed {
+	return &CompanyFeed{
+		replayer:    replayer,
+		buffer:      buffer,
+		subscribers: make(map[*FeedSubscription]struct{}),
+	}
+}
+
+// Publish sends consumed event to subscribers, subscribers with full buffer are dropped
+func (f *CompanyFeed) Publish(position string, e event.Event) {
+	feedEvent, err := newFeedEvent(position, e)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for subscription := range f.subscribers {
+		if subscription.filter != nil && !subscription.filter(feedEvent) {
+			continue
+		}
+		select {
+		case subscription.events <- feedEvent:
+		default:
+			log.Warn("company feed subscriber is too slow, disconnecting")
+			delete(f.subscribers, subscription)
+			close(subscription.events)
+		}
+	}
+}
+
+// Subscribe starts receiving live events accepted by filter, nil filter accepts all events
+func (f *CompanyFeed) Subscribe(filter FeedFilter) *FeedSubscription {
+	subscription := &FeedSubscription{
+		events: make(chan *FeedEvent, f.buffer),
+		filter: filter,
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subscribers[subscription] = struct{}{}
+	return subscription
+}
+
+// Unsubscribe stops sending events to subscription
+func (f *CompanyFeed) Unsubscribe(subscription *FeedSubscription) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subscribers[subscription]; ok {
+		delete(f.subscribers, subscription)
+		close(subscription.events)
+	}
+}
+
+// Replay sends events published after lastEventID which pass subscription filter.
+// Subscribe before replay so events published meanwhile are not lost, Replayed filters out duplicates.
+func (f *CompanyFeed) Replay(ctx context.Context, subscription *FeedSubscription, lastEventID string,
+	send func(e *FeedEvent) error) error {
+	last, err := bus.ParsePosition(lastEventID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEventID, err)
+	}
+	subscription.last = &last
+
+	for {
+		messages, err := f.replayer.Range(ctx, event.CompanyTopic, lastEventID, feedReplayPage)
+		if err != nil {
+			return err
+		}
+		for _, message := range messages {
+			lastEventID = message.Position
+			e, err := event.DecodeEnvelope(&message.Envelope)
+			if err != nil {
+				log.Errorf("skipped undecodable company message %s: %v", message.Position, err)
+				continue
+			}
+			feedEvent, err := newFeedEvent(message.Position, e)
+			if err != nil {
+				return err
+			}
+			subscription.last = &feedEvent.position
+			if subscription.filter != nil && !subscription.filter(feedEvent) {
+				continue
+			}
+			err = send(feedEvent)
+			if err != nil {
+				return err
+			}
+		}
+		if len(messages) < feedReplayPage {
+			return nil
+		}
+	}
+}
+
+func newFeedEvent(position string, e event.Event) (*FeedEvent, error) {
+	parsed, err := bus.ParsePosition(position)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return &FeedEvent{
+		ID:        position,
+		Type:      e.Type(),
+		CompanyID: e.AggregateID(),
+		Data:      data,
+		position:  parsed,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/bus"
+	"github.com/Entetry/gocompany/internal/event"
+)
+
+func publishCompany(t *testing.T, b bus.Bus, name string) (string, event.Event) {
+	e := &event.CompanyCreated{CompanyID: uuid.New(), Name: name}
+	envelope, err := event.NewEnvelope(e)
+	require.NoError(t, err)
+	position, err := b.Publish(context.Background(), event.CompanyTopic, envelope)
+	require.NoError(t, err)
+	return position, e
+}
+
+func TestCompanyFeed_Replay(t *testing.T) {
+	t.Log("Given the need to test feed resumes after last event id without duplicates.")
+	b := bus.NewMemory(0)
+	feed := NewCompanyFeed(b, 10)
+	first, _ := publishCompany(t, b, "Google")
+	second, secondEvent := publishCompany(t, b, "Apple")
+
+	subscription := feed.Subscribe(nil)
+	defer feed.Unsubscribe(subscription)
+	// consumer delivers already replayed event after client subscribed
+	feed.Publish(second, secondEvent)
+
+	var replayed []string
+	err := feed.Replay(context.Background(), subscription, first, func(e *FeedEvent) error {
+		replayed = append(replayed, e.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{second}, replayed)
+	require.True(t, subscription.Replayed(<-subscription.Events()))
+
+	third, thirdEvent := publishCompany(t, b, "Amazon")
+	feed.Publish(third, thirdEvent)
+	live := <-subscription.Events()
+	require.False(t, subscription.Replayed(live))
+	require.Equal(t, third, live.ID)
+	require.Equal(t, event.CREATE, live.Type)
+
+	err = feed.Replay(context.Background(), subscription, "not-an-id", nil)
+	require.ErrorIs(t, err, ErrInvalidEventID)
+}
+
+func TestCompanyFeed_SlowSubscriber(t *testing.T) {
+	t.Log("Given the need to test subscriber is dropped when its buffer is full.")
+	feed := NewCompanyFeed(bus.NewMemory(0), 1)
+	subscription := feed.Subscribe(nil)
+	filtered := feed.Subscribe(func(e *FeedEvent) bool { return false })
+	defer feed.Unsubscribe(filtered)
+
+	e := &event.CompanyDeleted{CompanyID: uuid.New()}
+	feed.Publish("1", e)
+	feed.Publish("2", e)
+
+	received, ok := <-subscription.Events()
+	require.True(t, ok)
+	require.Equal(t, "1", received.ID)
+	_, ok = <-subscription.Events()
+	require.False(t, ok)
+	require.Len(t, filtered.Events(), 0)
+	// unsubscribing dropped subscriber is a no-op
+	feed.Unsubscribe(subscription)
+}
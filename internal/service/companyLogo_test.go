@@ -0,0 +1,309 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/config"
+	"github.com/Entetry/gocompany/internal/imaging"
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/storage"
+)
+
+// fakeCompanyRepository keeps companies in memory
+type fakeCompanyRepository struct {
+	companies map[uuid.UUID]*model.Company
+}
+
+func (f *fakeCompanyRepository) Create(_ context.Context, company *model.Company) (uuid.UUID, error) {
+	company.ID = uuid.New()
+	f.companies[company.ID] = company
+	return company.ID, nil
+}
+
+func (f *fakeCompanyRepository) Update(_ context.Context, company *model.Company) error {
+	f.companies[company.ID] = company
+	return nil
+}
+
+func (f *fakeCompanyRepository) Delete(_ context.Context, id uuid.UUID) error {
+	delete(f.companies, id)
+	return nil
+}
+
+func (f *fakeCompanyRepository) GetOne(_ context.Context, id uuid.UUID) (*model.Company, error) {
+	company, ok := f.companies[id]
+	if !ok {
+		return nil, echo.ErrNotFound
+	}
+	return company, nil
+}
+
+func (f *fakeCompanyRepository) GetAll(_ context.Context) ([]*model.Company, error) {
+	var companies []*model.Company
+	for _, company := range f.companies {
+		companies = append(companies, company)
+	}
+	return companies, nil
+}
+
+// addTestCompany creates company logos can be added to
+func addTestCompany(t *testing.T, companyService *Company) uuid.UUID {
+	id, err := companyService.companyRepository.Create(context.Background(), &model.Company{Name: "Google"})
+	require.NoError(t, err)
+	return id
+}
+
+// fakeLogoRepository keeps logo versions and blob references in memory
+type fakeLogoRepository struct {
+	versions map[uuid.UUID][]*model.Logo
+	blobs    map[string]*model.LogoBlob
+}
+
+func (f *fakeLogoRepository) Create(_ context.Context, companyID uuid.UUID, storageKey, contentType string) error {
+	if f.current(companyID) != nil {
+		return errors.New("duplicate current logo")
+	}
+	f.addVersion(companyID, storageKey, contentType)
+	return nil
+}
+
+func (f *fakeLogoRepository) GetByCompanyID(_ context.Context, companyID uuid.UUID) (*model.Logo, error) {
+	return f.current(companyID), nil
+}
+
+func (f *fakeLogoRepository) GetVersions(_ context.Context, companyID uuid.UUID) ([]*model.Logo, error) {
+	var versions []*model.Logo
+	for i := len(f.versions[companyID]) - 1; i >= 0; i-- {
+		versions = append(versions, f.versions[companyID][i])
+	}
+	return versions, nil
+}
+
+func (f *fakeLogoRepository) GetVersion(_ context.Context, companyID uuid.UUID, version int) (*model.Logo, error) {
+	if version < 1 || version > len(f.versions[companyID]) {
+		return nil, nil
+	}
+	return f.versions[companyID][version-1], nil
+}
+
+func (f *fakeLogoRepository) GetBlobs(_ context.Context) ([]*model.LogoBlob, error) {
+	var blobs []*model.LogoBlob
+	for _, blob := range f.blobs {
+		blobs = append(blobs, blob)
+	}
+	return blobs, nil
+}
+
+func (f *fakeLogoRepository) GetBlob(_ context.Context, storageKey string) (*model.LogoBlob, error) {
+	return f.blobs[storageKey], nil
+}
+
+func (f *fakeLogoRepository) Replace(_ context.Context, companyID uuid.UUID, storageKey, contentType string) error {
+	if current := f.current(companyID); current != nil {
+		current.Current = false
+	}
+	f.addVersion(companyID, storageKey, contentType)
+	return nil
+}
+
+func (f *fakeLogoRepository) Rollback(ctx context.Context, companyID uuid.UUID, version int) (*model.Logo, error) {
+	logo, _ := f.GetVersion(ctx, companyID, version)
+	if logo == nil {
+		return nil, nil
+	}
+	if current := f.current(companyID); current != nil {
+		current.Current = false
+	}
+	logo.Current = true
+	return logo, nil
+}
+
+func (f *fakeLogoRepository) Delete(_ context.Context, companyID uuid.UUID) (*model.Logo, error) {
+	current := f.current(companyID)
+	if current != nil {
+		current.Current = false
+	}
+	return current, nil
+}
+
+func (f *fakeLogoRepository) current(companyID uuid.UUID) *model.Logo {
+	for _, logo := range f.versions[companyID] {
+		if logo.Current {
+			return logo
+		}
+	}
+	return nil
+}
+
+func (f *fakeLogoRepository) addVersion(companyID uuid.UUID, storageKey, contentType string) {
+	f.versions[companyID] = append(f.versions[companyID], &model.Logo{ID: uuid.New(), CompanyID: companyID,
+		StorageKey: storageKey, ContentType: contentType, Version: len(f.versions[companyID]) + 1, Current: true})
+	if f.blobs[storageKey] == nil {
+		f.blobs[storageKey] = &model.LogoBlob{StorageKey: storageKey, ContentType: contentType}
+	}
+	f.blobs[storageKey].RefCount++
+}
+
+func newLogoService(t *testing.T) (*Company, *fakeLogoRepository, storage.BlobStore) {
+	logoRepository := &fakeLogoRepository{versions: make(map[uuid.UUID][]*model.Logo),
+		blobs: make(map[string]*model.LogoBlob)}
+	blobStore := storage.NewLocal(t.TempDir())
+	logoCfg := &config.LogoConfig{MaxBytes: 1 << 20, MaxWidth: 1024, MaxHeight: 1024, Renditions: []int{128, 32, 64}}
+	companyRepository := &fakeCompanyRepository{companies: make(map[uuid.UUID]*model.Company)}
+	return NewCompany(companyRepository, logoRepository, blobStore, logoCfg, nil, nil, false), logoRepository,
+		blobStore
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+// newFileHeader builds multipart file header the way echo parses uploads
+func newFileHeader(t *testing.T, content []byte) *multipart.FileHeader {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("image", "logo")
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	request := httptest.NewRequest("POST", "/company/logo", &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	_, header, err := request.FormFile("image")
+	require.NoError(t, err)
+	return header
+}
+
+func readLogo(t *testing.T, logo *LogoFile) image.Config {
+	data, err := io.ReadAll(logo.Content)
+	require.NoError(t, err)
+	config, err := png.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	return config
+}
+
+func TestCompany_LogoRenditions(t *testing.T) {
+	t.Log("Given the need to test nearest logo rendition is generated and served.")
+	ctx := context.Background()
+	companyService, logoRepository, _ := newLogoService(t)
+	companyID := addTestCompany(t, companyService)
+
+	require.NoError(t, companyService.AddLogo(ctx, companyID.String(), newFileHeader(t, encodePNG(t, 400, 200))))
+	require.Equal(t, imaging.PNG, logoRepository.current(companyID).ContentType)
+
+	logo, err := companyService.GetLogo(ctx, companyID, 0)
+	require.NoError(t, err)
+	require.Equal(t, 400, readLogo(t, logo).Width)
+
+	for _, size := range []int{60, 48, 1000} {
+		expected := map[int]int{60: 64, 48: 64, 1000: 128}[size]
+		var etag string
+		for i := 0; i < 2; i++ {
+			logo, err = companyService.GetLogo(ctx, companyID, size)
+			require.NoError(t, err)
+			require.Equal(t, imaging.PNG, logo.ContentType)
+			if i > 0 {
+				// stored rendition must keep ETag of generated one
+				require.Equal(t, etag, logo.ETag)
+			}
+			etag = logo.ETag
+			config := readLogo(t, logo)
+			require.Equal(t, expected, config.Width)
+			require.Equal(t, expected/2, config.Height)
+		}
+	}
+
+	_, err = companyService.GetLogo(ctx, uuid.New(), 64)
+	require.ErrorIs(t, err, ErrLogoNotFound)
+
+	err = companyService.AddLogo(ctx, addTestCompany(t, companyService).String(),
+		newFileHeader(t, []byte("not an image")))
+	require.ErrorIs(t, err, imaging.ErrUnsupportedFormat)
+}
+
+func TestCompany_LogoUnknownCompany(t *testing.T) {
+	t.Log("Given the need to test logo isn't stored for company which doesn't exist.")
+	ctx := context.Background()
+	companyService, logoRepository, blobStore := newLogoService(t)
+	companyID := uuid.New()
+
+	err := companyService.ReplaceLogo(ctx, companyID, newFileHeader(t, encodePNG(t, 100, 100)))
+	require.ErrorIs(t, err, ErrCompanyNotFound)
+	err = companyService.AddLogo(ctx, companyID.String(), newFileHeader(t, encodePNG(t, 100, 100)))
+	require.ErrorIs(t, err, ErrCompanyNotFound)
+	require.Empty(t, logoRepository.versions)
+	err = blobStore.List(ctx, "", func(blob storage.BlobInfo) error {
+		return fmt.Errorf("unexpected blob %s", blob.Key)
+	})
+	require.NoError(t, err)
+}
+
+func TestCompany_LogoVersions(t *testing.T) {
+	t.Log("Given the need to test replaced and deleted logos are kept as versions to roll back to.")
+	ctx := context.Background()
+	companyService, _, blobStore := newLogoService(t)
+	companyID := addTestCompany(t, companyService)
+
+	require.NoError(t, companyService.ReplaceLogo(ctx, companyID, newFileHeader(t, encodePNG(t, 400, 200))))
+	require.NoError(t, companyService.ReplaceLogo(ctx, companyID, newFileHeader(t, encodePNG(t, 100, 100))))
+	logo, err := companyService.GetLogo(ctx, companyID, 0)
+	require.NoError(t, err)
+	require.Equal(t, 100, readLogo(t, logo).Width)
+
+	versions, err := companyService.GetLogoVersions(ctx, companyID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	require.Equal(t, 2, versions[0].Version)
+	require.True(t, versions[0].Current)
+	require.False(t, versions[1].Current)
+	_, err = blobStore.Get(ctx, versions[1].StorageKey)
+	require.NoError(t, err, "previous logo blob is kept")
+
+	logo, err = companyService.GetLogoVersion(ctx, companyID, 1, 64)
+	require.NoError(t, err)
+	require.Equal(t, 64, readLogo(t, logo).Width)
+	_, err = companyService.GetLogoVersion(ctx, companyID, 3, 0)
+	require.ErrorIs(t, err, ErrLogoVersionNotFound)
+
+	require.NoError(t, companyService.DeleteLogo(ctx, companyID))
+	_, err = companyService.GetLogo(ctx, companyID, 0)
+	require.ErrorIs(t, err, ErrLogoNotFound)
+	require.ErrorIs(t, companyService.DeleteLogo(ctx, companyID), ErrLogoNotFound)
+
+	require.NoError(t, companyService.RollbackLogo(ctx, companyID, 1))
+	logo, err = companyService.GetLogo(ctx, companyID, 0)
+	require.NoError(t, err)
+	require.Equal(t, 400, readLogo(t, logo).Width)
+	require.ErrorIs(t, companyService.RollbackLogo(ctx, companyID, 3), ErrLogoVersionNotFound)
+}
+
+func TestCompany_SharedLogo(t *testing.T) {
+	t.Log("Given the need to test identical logos share one blob.")
+	ctx := context.Background()
+	companyService, logoRepository, _ := newLogoService(t)
+	first, second := addTestCompany(t, companyService), addTestCompany(t, companyService)
+	content := encodePNG(t, 100, 100)
+
+	require.NoError(t, companyService.AddLogo(ctx, first.String(), newFileHeader(t, content)))
+	require.NoError(t, companyService.ReplaceLogo(ctx, second, newFileHeader(t, content)))
+	storageKey := logoRepository.current(first).StorageKey
+	require.Equal(t, storageKey, logoRepository.current(second).StorageKey)
+	require.Equal(t, buildLogoKey(content, imaging.PNG), storageKey)
+	require.Equal(t, 2, logoRepository.blobs[storageKey].RefCount)
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Entetry/gocompany/internal/bus"
+	"github.com/Entetry/gocompany/internal/dlq"
+)
+
+const maxDeadLetterPage = 100
+
+// DeadLetter service of dead-lettered company messages
+type DeadLetter struct {
+	deadLetter dlq.DeadLetter
+	publisher  bus.Publisher
+}
+
+// NewDeadLetter creates new DeadLetter service
+func NewDeadLetter(deadLetter dlq.DeadLetter, publisher bus.Publisher) *DeadLetter {
+	return &DeadLetter{deadLetter: deadLetter, publisher: publisher}
+}
+
+// List returns page of dead-lettered messages starting from id start
+func (d *DeadLetter) List(ctx context.Context, start string, count int64) ([]*dlq.Message, error) {
+	if count <= 0 || count > maxDeadLetterPage {
+		count = maxDeadLetterPage
+	}
+	return d.deadLetter.List(ctx, start, count)
+}
+
+// Get return dead-lettered message
+func (d *DeadLetter) Get(ctx context.Context, id string) (*dlq.Message, error) {
+	return d.deadLetter.Get(ctx, id)
+}
+
+// Redrive puts original payload of dead-lettered message back to its topic and returns its new position,
+// only consumer group which dead-lettered message handles it again
+func (d *DeadLetter) Redrive(ctx context.Context, id string) (string, error) {
+	message, err := d.deadLetter.Get(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	fields := make(map[string]string, len(message.Payload)+1)
+	for k, v := range message.Payload {
+		fields[k] = v
+	}
+	fields[bus.RedriveGroupField] = message.Group
+	position, err := d.publisher.PublishRaw(ctx, message.Stream, fields)
+	if err != nil {
+		return "", fmt.Errorf("cannot redrive message %s: %v", id, err)
+	}
+	err = d.deadLetter.Delete(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return position, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/config"
+	"github.com/Entetry/gocompany/internal/mail"
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/repository"
+)
+
+const verificationSubject = "Verify your email"
+
+// EmailVerification service confirming users own their emails
+type EmailVerification struct {
+	userRepository repository.UserRepository
+	userTokens     *UserTokens
+	sender         mail.Sender
+	cfg            *config.MailConfig
+}
+
+// NewEmailVerification creates new EmailVerification service
+func NewEmailVerification(userRepository repository.UserRepository, userTokens *UserTokens,
+	sender mail.Sender, cfg *config.MailConfig) *EmailVerification {
+	return &EmailVerification{
+		userRepository: userRepository,
+		userTokens:     userTokens,
+		sender:         sender,
+		cfg:            cfg,
+	}
+}
+
+// Required reports whether pending users are refused to sign in
+func (e *EmailVerification) Required() bool {
+	return e.cfg.VerificationRequired
+}
+
+// Send emails verification link to user, earlier links stop working
+func (e *EmailVerification) Send(ctx context.Context, user *model.User) error {
+	token, err := e.userTokens.Issue(ctx, user.ID, model.TokenEmailVerification, e.cfg.VerificationTTL)
+	if err != nil {
+		return err
+	}
+	return e.sender.Send(ctx, &mail.Message{
+		To:      user.Email,
+		Subject: verificationSubject,
+		Body: fmt.Sprintf("Hello %s,\n\nconfirm your email by opening the link below:\n%s%s\n\n"+
+			"The link expires in %s. If you didn't sign up, ignore this email.\n",
+			user.Username, e.cfg.VerificationURL, url.QueryEscape(token), e.cfg.VerificationTTL),
+	})
+}
+
+// Verify activates user of token
+func (e *EmailVerification) Verify(ctx context.Context, token string) error {
+	userID, err := e.userTokens.Consume(ctx, token, model.TokenEmailVerification)
+	if err != nil {
+		return err
+	}
+	user, err := e.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidToken
+	}
+	if user.Status == model.UserActive {
+		return nil
+	}
+	return e.userRepository.UpdateStatus(ctx, user.ID, model.UserActive)
+}
+
+// Resend sends verification link again to pending users with email,
+// response doesn't tell whether such user exists and users are emailed at most once per resend interval
+func (e *EmailVerification) Resend(ctx context.Context, email string) error {
+	users, err := e.userRepository.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.Status != model.UserPending {
+			continue
+		}
+		recent, err := e.userTokens.IssuedWithin(ctx, user.ID, model.TokenEmailVerification, e.cfg.ResendInterval)
+		if err != nil {
+			return err
+		}
+		if recent {
+			log.Infof("verification email to user %s was sent recently, skipping", user.ID)
+			continue
+		}
+		err = e.Send(ctx, user)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+func TestAuth_EmailVerification(t *testing.T) {
+	t.Log("Given the need to test signed up users have to verify email before signing in.")
+	f := newAuthFixture()
+	ctx := context.Background()
+
+	require.NoError(t, f.auth.SignUp(ctx, "alice", "secret-password", "alice@example.com"))
+	user, err := f.users.GetByUsername(ctx, "alice")
+	require.NoError(t, err)
+	require.Equal(t, model.UserPending, user.Status)
+	require.Len(t, f.sender.messages, 1)
+	require.Equal(t, "alice@example.com", f.sender.messages[0].To)
+
+	_, _, err = f.auth.SignIn(ctx, "alice", "secret-password", &model.TokenParam{})
+	require.ErrorIs(t, err, ErrEmailNotVerified)
+	_, _, err = f.auth.SignIn(ctx, "nobody", "secret-password", &model.TokenParam{})
+	require.Error(t, err)
+
+	token := f.sender.lastToken(t, f.mailCfg.VerificationURL)
+	require.ErrorIs(t, f.auth.VerifyEmail(ctx, token+"x"), ErrInvalidToken)
+	require.ErrorIs(t, f.auth.VerifyEmail(ctx, "garbage"), ErrInvalidToken)
+	require.NoError(t, f.auth.VerifyEmail(ctx, token))
+	user, err = f.users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.UserActive, user.Status)
+	require.ErrorIs(t, f.auth.VerifyEmail(ctx, token), ErrInvalidToken)
+}
+
+func TestAuth_ResendVerification(t *testing.T) {
+	t.Log("Given the need to test verification email is resent at most once per interval to pending users only.")
+	f := newAuthFixture()
+	ctx := context.Background()
+	require.NoError(t, f.auth.SignUp(ctx, "bob", "secret-password", "bob@example.com"))
+	firstToken := f.sender.lastToken(t, f.mailCfg.VerificationURL)
+
+	require.NoError(t, f.auth.ResendVerification(ctx, "BOB@example.com"))
+	require.Len(t, f.sender.messages, 1)
+	require.NoError(t, f.auth.ResendVerification(ctx, "unknown@example.com"))
+	require.Len(t, f.sender.messages, 1)
+
+	f.mailCfg.ResendInterval = 0
+	require.NoError(t, f.auth.ResendVerification(ctx, "bob@example.com"))
+	require.Len(t, f.sender.messages, 2)
+	require.ErrorIs(t, f.auth.VerifyEmail(ctx, firstToken), ErrInvalidToken)
+	require.NoError(t, f.auth.VerifyEmail(ctx, f.sender.lastToken(t, f.mailCfg.VerificationURL)))
+
+	require.NoError(t, f.auth.ResendVerification(ctx, "bob@example.com"))
+	require.Len(t, f.sender.messages, 2)
+}
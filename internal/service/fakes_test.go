@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Entetry/gocompany/internal/config"
+	"github.com/Entetry/gocompany/internal/mail"
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/password"
+	"github.com/Entetry/gocompany/internal/repository"
+)
+
+type fakeUserRepository struct {
+	mu    sync.Mutex
+	users map[uuid.UUID]*model.User
+}
+
+func (f *fakeUserRepository) Create(_ context.Context, username, pwdHash, email, status string) (uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user := &model.User{ID: uuid.New(), Username: username, Email: email, PasswordHash: pwdHash, Status: status}
+	if f.usernameTaken(user) {
+		return uuid.Nil, repository.ErrDuplicateUsername
+	}
+	f.users[user.ID] = user
+	return user.ID, nil
+}
+
+// usernameTaken checks unique username like index of users table, must be called with lock held
+func (f *fakeUserRepository) usernameTaken(user *model.User) bool {
+	for _, other := range f.users {
+		if other.ID != user.ID && other.Username == user.Username {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeUserRepository) GetByUsername(_ context.Context, username string) (*model.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, user := range f.users {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeUserRepository) GetByID(_ context.Context, id uuid.UUID) (*model.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user, ok := f.users[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (f *fakeUserRepository) GetByEmail(_ context.Context, email string) ([]*model.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var users []*model.User
+	for _, user := range f.users {
+		if strings.EqualFold(user.Email, email) {
+			copied := *user
+			users = append(users, &copied)
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeUserRepository) UpdateStatus(_ context.Context, id uuid.UUID, status string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.users[id].Status = status
+	return nil
+}
+
+func (f *fakeUserRepository) UpdatePassword(_ context.Context, id uuid.UUID, pwdHash string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.users[id].PasswordHash = pwdHash
+	return nil
+}
+
+func (f *fakeUserRepository) Update(_ context.Context, user *model.User) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.usernameTaken(user) {
+		return repository.ErrDuplicateUsername
+	}
+	copied := *user
+	f.users[user.ID] = &copied
+	return nil
+}
+
+func (f *fakeUserRepository) Delete(_ context.Context, id uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.users, id)
+	return nil
+}
+
+type fakeRefreshSessionRepository struct {
+	mu       sync.Mutex
+	sessions map[string]*model.RefreshSession
+}
+
+func (f *fakeRefreshSessionRepository) Create(_ context.Context, session *model.RefreshSession) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions[session.RefreshToken] = session
+	return nil
+}
+
+func (f *fakeRefreshSessionRepository) GetByRefreshToken(_ context.Context,
+	refreshToken string) (*model.RefreshSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	session, ok := f.sessions[refreshToken]
+	if !ok {
+		return nil, fmt.Errorf("refresh session not found")
+	}
+	return session, nil
+}
+
+func (f *fakeRefreshSessionRepository) Count(_ context.Context, userID string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, session := range f.sessions {
+		if session.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeRefreshSessionRepository) Delete(_ context.Context, refreshToken string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.sessions, refreshToken)
+	return nil
+}
+
+func (f *fakeRefreshSessionRepository) DeleteUserSessions(_ context.Context, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for token, session := range f.sessions {
+		if session.UserID == userID {
+			delete(f.sessions, token)
+		}
+	}
+	return nil
+}
+
+type fakeUserTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]*model.UserToken
+	used   map[string]bool
+}
+
+func (f *fakeUserTokenRepository) Create(_ context.Context, token *model.UserToken) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	token.CreatedAt = time.Now()
+	f.tokens[token.Hash] = token
+	return nil
+}
+
+func (f *fakeUserTokenRepository) Consume(_ context.Context, hash, purpose string) (uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	token, ok := f.tokens[hash]
+	if !ok || token.Purpose != purpose || f.used[hash] || !token.ExpiresAt.After(time.Now()) {
+		return uuid.Nil, nil
+	}
+	f.used[hash] = true
+	return token.UserID, nil
+}
+
+func (f *fakeUserTokenRepository) GetUserID(_ context.Context, hash, purpose string) (uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	token, ok := f.tokens[hash]
+	if !ok || token.Purpose != purpose || f.used[hash] || !token.ExpiresAt.After(time.Now()) {
+		return uuid.Nil, nil
+	}
+	return token.UserID, nil
+}
+
+func (f *fakeUserTokenRepository) DeleteByUser(_ context.Context, userID uuid.UUID, purpose string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for hash, token := range f.tokens {
+		if token.UserID == userID && token.Purpose == purpose {
+			delete(f.tokens, hash)
+		}
+	}
+	return nil
+}
+
+func (f *fakeUserTokenRepository) LastCreatedAt(_ context.Context, userID uuid.UUID, purpose string) (*time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var last *time.Time
+	for _, token := range f.tokens {
+		if token.UserID == userID && token.Purpose == purpose && (last == nil || token.CreatedAt.After(*last)) {
+			createdAt := token.CreatedAt
+			last = &createdAt
+		}
+	}
+	return last, nil
+}
+
+type fakeSender struct {
+	mu       sync.Mutex
+	messages []*mail.Message
+	// err fails sending when set
+	err error
+	// block delays sending until it is closed when set
+	block chan struct{}
+}
+
+func (f *fakeSender) Send(ctx context.Context, message *mail.Message) error {
+	if f.block != nil {
+		select {
+		case <-f.block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.messages = append(f.messages, message)
+	return nil
+}
+
+// lastToken extracts token from link of last sent email
+func (f *fakeSender) lastToken(t *testing.T, linkPrefix string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	require.NotEmpty(t, f.messages)
+	body := f.messages[len(f.messages)-1].Body
+	start := strings.Index(body, linkPrefix)
+	require.GreaterOrEqual(t, start, 0)
+	escaped := strings.Fields(body[start+len(linkPrefix):])[0]
+	token, err := url.QueryUnescape(escaped)
+	require.NoError(t, err)
+	return token
+}
+
+// newTestHasher hashes with cheap parameters to keep tests fast
+func newTestHasher() *password.Hasher {
+	return password.NewHasher(&password.Argon2id{Time: 1, Memory: 64, Threads: 1}, &password.Bcrypt{Cost: bcrypt.MinCost})
+}
+
+type authFixture struct {
+	auth          *Auth
+	account       *Account
+	passwordReset *PasswordReset
+	users         *fakeUserRepository
+	sessions      *fakeRefreshSessionRepository
+	tokens        *UserTokens
+	sender        *fakeSender
+	mailCfg       *config.MailConfig
+}
+
+func newAuthFixture() *authFixture {
+	users := &fakeUserRepository{users: make(map[uuid.UUID]*model.User)}
+	sessions := &fakeRefreshSessionRepository{sessions: make(map[string]*model.RefreshSession)}
+	tokenRepository := &fakeUserTokenRepository{tokens: make(map[string]*model.UserToken), used: make(map[string]bool)}
+	tokens := NewUserTokens(tokenRepository, "test-key")
+	sender := &fakeSender{}
+	mailCfg := &config.MailConfig{
+		VerificationRequired: true,
+		VerificationTTL:      time.Hour,
+		VerificationURL:      "http://app/verify?token=",
+		PasswordResetTTL:     time.Hour,
+		PasswordResetURL:     "http://app/reset?token=",
+		ResendInterval:       time.Minute,
+	}
+	jwtCfg := &config.JwtConfig{AccessTokenKey: "test-key", AccessTokenExpiration: time.Hour,
+		RefreshTokenExpiration: time.Hour}
+	policy := &password.Policy{MinLength: 8, MaxLength: 64, MinClasses: 2, RejectUserInfo: true}
+	userService := NewUserService(users, newTestHasher(), policy)
+	refreshSession := NewRefreshSession(sessions)
+	emailVerification := NewEmailVerification(users, tokens, sender, mailCfg)
+	passwordReset := NewPasswordReset(userService, tokens, sender, refreshSession, mailCfg)
+	return &authFixture{
+		auth:          NewAuthService(userService, refreshSession, emailVerification, passwordReset, jwtCfg),
+		account:       NewAccount(userService, refreshSession, emailVerification),
+		passwordReset: passwordReset,
+		users:         users,
+		sessions:      sessions,
+		tokens:        tokens,
+		sender:        sender,
+		mailCfg:       mailCfg,
+	}
+}
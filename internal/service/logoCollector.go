@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/config"
+	"github.com/Entetry/gocompany/internal/imaging"
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/repository"
+	"github.com/Entetry/gocompany/internal/storage"
+)
+
+// LogoCollection result of logo garbage collection
+type LogoCollection struct {
+	// Scanned count of listed logo blobs
+	Scanned int
+	// Orphans keys of blobs no logo references, deleted unless collection was a dry run
+	Orphans []string
+	// Dangling referenced blobs which are missing in blob store
+	Dangling []*model.LogoBlob
+}
+
+// LogoCollector reconciles logo table against blob store
+type LogoCollector struct {
+	logoRepository repository.LogoRepository
+	blobStore      storage.BlobStore
+	renditions     []int
+	grace          time.Duration
+}
+
+// NewLogoCollector creates new LogoCollector
+func NewLogoCollector(logoRepository repository.LogoRepository, blobStore storage.BlobStore,
+	logoCfg *config.LogoConfig) *LogoCollector {
+	return &LogoCollector{logoRepository: logoRepository, blobStore: blobStore,
+		renditions: append([]int(nil), logoCfg.Renditions...), grace: logoCfg.GCGrace}
+}
+
+// Run collects garbage every interval until ctx is canceled
+func (l *LogoCollector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			collection, err := l.Collect(ctx, false)
+			if err != nil {
+				log.Errorf("logo garbage collection failed: %v", err)
+				continue
+			}
+			collection.Log()
+		}
+	}
+}
+
+// Collect deletes logo blobs and renditions without counted references and reports referenced blobs which
+// are missing, dryRun only reports orphans. Orphans younger than grace period are kept.
+func (l *LogoCollector) Collect(ctx context.Context, dryRun bool) (*LogoCollection, error) {
+	blobs, err := l.logoRepository.GetBlobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[string]bool)
+	for _, blob := range blobs {
+		referenced[blob.StorageKey] = true
+		if !imaging.Resizable(blob.ContentType) {
+			continue
+		}
+		renditionType := imaging.RenditionType(blob.ContentType)
+		for _, rendition := range l.renditions {
+			referenced[buildRenditionKey(blob.StorageKey, rendition, renditionType)] = true
+		}
+	}
+
+	collection := new(LogoCollection)
+	listed := make(map[string]bool)
+	// legacy logos were stored at store root, so whole store is listed
+	err = l.blobStore.List(ctx, "", func(blob storage.BlobInfo) error {
+		if !isLogoKey(blob.Key) {
+			return nil
+		}
+		collection.Scanned++
+		listed[blob.Key] = true
+		if referenced[blob.Key] || time.Since(blob.ModTime) < l.grace {
+			return nil
+		}
+		if !dryRun {
+			deleteErr := l.blobStore.Delete(ctx, blob.Key)
+			if deleteErr != nil {
+				return deleteErr
+			}
+		}
+		collection.Orphans = append(collection.Orphans, blob.Key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, blob := range blobs {
+		if listed[blob.StorageKey] {
+			continue
+		}
+		dangling, err := l.dangling(ctx, blob)
+		if err != nil {
+			return nil, err
+		}
+		if dangling {
+			collection.Dangling = append(collection.Dangling, blob)
+		}
+	}
+	return collection, nil
+}
+
+// isLogoKey reports whether blob is logo or rendition, either under logo prefix or legacy <company id>.jpeg
+// kept at store root and its renditions
+func isLogoKey(key string) bool {
+	if strings.HasPrefix(key, logoKeyPrefix) {
+		return true
+	}
+	if strings.Contains(key, "/") {
+		return false
+	}
+	stem := strings.TrimSuffix(key, path.Ext(key))
+	if i := strings.IndexByte(stem, '_'); i >= 0 {
+		stem = stem[:i]
+	}
+	_, err := uuid.Parse(stem)
+	return err == nil
+}
+
+// dangling checks unlisted blob again, it may be uploaded or released since references were read
+func (l *LogoCollector) dangling(ctx context.Context, blob *model.LogoBlob) (bool, error) {
+	current, err := l.logoRepository.GetBlob(ctx, blob.StorageKey)
+	if err != nil || current == nil {
+		return false, err
+	}
+	content, err := l.blobStore.Get(ctx, blob.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	closeErr := content.Close()
+	if closeErr != nil {
+		log.Error(closeErr)
+	}
+	return false, nil
+}
+
+// Log writes collection summary and every dangling logo to log
+func (c *LogoCollection) Log() {
+	log.Infof("logo garbage collection scanned %d blobs, found %d orphans and %d dangling references",
+		c.Scanned, len(c.Orphans), len(c.Dangling))
+	for _, blob := range c.Dangling {
+		log.Warnf("%d logo versions reference missing blob %s", blob.RefCount, blob.StorageKey)
+	}
+}
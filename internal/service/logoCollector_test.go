@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/config"
+	"github.com/Entetry/gocompany/internal/imaging"
+	"github.com/Entetry/gocompany/internal/storage"
+)
+
+func TestLogoCollector_Collect(t *testing.T) {
+	t.Log("Given the need to test orphaned logo blobs are deleted and dangling references reported.")
+	ctx := context.Background()
+	companyService, logoRepository, blobStore := newLogoService(t)
+	kept, dangling := addTestCompany(t, companyService), addTestCompany(t, companyService)
+
+	require.NoError(t, companyService.AddLogo(ctx, kept.String(), newFileHeader(t, encodePNG(t, 100, 100))))
+	_, err := companyService.GetLogo(ctx, kept, 64)
+	require.NoError(t, err)
+	require.NoError(t, companyService.AddLogo(ctx, dangling.String(), newFileHeader(t, encodePNG(t, 50, 50))))
+	require.NoError(t, blobStore.Delete(ctx, logoRepository.current(dangling).StorageKey))
+	orphan := "logo/orphan.png"
+	require.NoError(t, blobStore.Put(ctx, orphan, bytes.NewReader([]byte("orphan")), 6))
+	legacy, legacyOrphan := uuid.New(), uuid.NewString()+".jpeg"
+	require.NoError(t, logoRepository.Create(ctx, legacy, legacy.String()+".jpeg", imaging.JPEG))
+	attachment := "attachment/" + uuid.NewString() + "/0-" + uuid.NewString()
+	for _, key := range []string{legacy.String() + ".jpeg", legacyOrphan, attachment} {
+		require.NoError(t, blobStore.Put(ctx, key, bytes.NewReader([]byte("blob")), 4))
+	}
+
+	collector := NewLogoCollector(logoRepository, blobStore, &config.LogoConfig{Renditions: []int{64},
+		GCGrace: time.Hour})
+	collection, err := collector.Collect(ctx, false)
+	require.NoError(t, err)
+	require.Empty(t, collection.Orphans, "young orphan is kept")
+
+	collector.grace = 0
+	collection, err = collector.Collect(ctx, true)
+	require.NoError(t, err)
+	require.Equal(t, 5, collection.Scanned, "legacy logos at store root are scanned, attachments aren't")
+	require.ElementsMatch(t, []string{orphan, legacyOrphan}, collection.Orphans)
+	require.Len(t, collection.Dangling, 1)
+	require.Equal(t, logoRepository.current(dangling).StorageKey, collection.Dangling[0].StorageKey)
+	require.Equal(t, 1, collection.Dangling[0].RefCount)
+	_, err = blobStore.Get(ctx, orphan)
+	require.NoError(t, err, "dry run keeps orphan")
+
+	collection, err = collector.Collect(ctx, false)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{orphan, legacyOrphan}, collection.Orphans)
+	for _, key := range []string{orphan, legacyOrphan} {
+		_, err = blobStore.Get(ctx, key)
+		require.ErrorIs(t, err, storage.ErrNotFound)
+	}
+	keptKey := logoRepository.current(kept).StorageKey
+	for _, key := range []string{keptKey, buildRenditionKey(keptKey, 64, imaging.PNG), legacy.String() + ".jpeg",
+		attachment} {
+		_, err = blobStore.Get(ctx, key)
+		require.NoError(t, err)
+	}
+}
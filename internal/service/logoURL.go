@@ -0,0 +1,126 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Entetry/gocompany/internal/config"
+)
+
+var (
+	// ErrInvalidLogoURL public logo url is malformed or its signature doesn't match
+	ErrInvalidLogoURL = errors.New("invalid logo url signature")
+	// ErrLogoURLExpired public logo url expired
+	ErrLogoURLExpired = errors.New("logo url expired")
+	// ErrLogoURLTTL requested url lifetime is out of allowed range
+	ErrLogoURLTTL = errors.New("logo url ttl is out of range")
+)
+
+// LogoURL logo addressed by public url, zero Version is current logo and zero Size is original
+type LogoURL struct {
+	CompanyID uuid.UUID
+	Version   int
+	Size      int
+	ExpiresAt time.Time
+}
+
+// LogoURLSigner signs and verifies query of public logo urls with HMAC-SHA256
+type LogoURLSigner struct {
+	// keyIDs in config order, the first one signs new urls
+	keyIDs []string
+	keys   map[string][]byte
+	ttl    time.Duration
+	maxTTL time.Duration
+}
+
+// NewLogoURLSigner creates new LogoURLSigner from id:secret keys of config
+func NewLogoURLSigner(logoCfg *config.LogoConfig) (*LogoURLSigner, error) {
+	signer := &LogoURLSigner{keys: make(map[string][]byte), ttl: logoCfg.URLTTL, maxTTL: logoCfg.URLMaxTTL}
+	for _, key := range logoCfg.URLKeys {
+		id, secret, ok := strings.Cut(key, ":")
+		if !ok || id == "" || secret == "" {
+			return nil, fmt.Errorf("logo url key must be id:secret")
+		}
+		if _, ok = signer.keys[id]; ok {
+			return nil, fmt.Errorf("duplicate logo url key %s", id)
+		}
+		signer.keyIDs = append(signer.keyIDs, id)
+		signer.keys[id] = []byte(secret)
+	}
+	if len(signer.keyIDs) == 0 {
+		return nil, fmt.Errorf("no logo url keys")
+	}
+	return signer, nil
+}
+
+// Sign returns signed query of url expiring after ttl, zero ttl is default one
+func (s *LogoURLSigner) Sign(logo LogoURL, ttl time.Duration, now time.Time) (url.Values, time.Time, error) {
+	if ttl == 0 {
+		ttl = s.ttl
+	}
+	if ttl < 0 || ttl > s.maxTTL {
+		return nil, time.Time{}, fmt.Errorf("%w: at most %s", ErrLogoURLTTL, s.maxTTL)
+	}
+	logo.ExpiresAt = now.Add(ttl).Truncate(time.Second)
+
+	keyID := s.keyIDs[0]
+	query := url.Values{}
+	if logo.Version > 0 {
+		query.Set("version", strconv.Itoa(logo.Version))
+	}
+	if logo.Size > 0 {
+		query.Set("size", strconv.Itoa(logo.Size))
+	}
+	query.Set("expires", strconv.FormatInt(logo.ExpiresAt.Unix(), 10))
+	query.Set("kid", keyID)
+	query.Set("sig", signLogoURL(s.keys[keyID], &logo))
+	return query, logo.ExpiresAt, nil
+}
+
+// Verify checks signature and expiry of public url query of company logo
+func (s *LogoURLSigner) Verify(companyID uuid.UUID, query url.Values, now time.Time) (*LogoURL, error) {
+	logo := &LogoURL{CompanyID: companyID}
+	var err error
+	if version := query.Get("version"); version != "" {
+		logo.Version, err = strconv.Atoi(version)
+		if err != nil || logo.Version <= 0 {
+			return nil, ErrInvalidLogoURL
+		}
+	}
+	if size := query.Get("size"); size != "" {
+		logo.Size, err = strconv.Atoi(size)
+		if err != nil || logo.Size <= 0 {
+			return nil, ErrInvalidLogoURL
+		}
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidLogoURL
+	}
+	logo.ExpiresAt = time.Unix(expires, 0)
+
+	key, ok := s.keys[query.Get("kid")]
+	if !ok || !hmac.Equal([]byte(query.Get("sig")), []byte(signLogoURL(key, logo))) {
+		return nil, ErrInvalidLogoURL
+	}
+	// expiry is checked only after signature, so expired urls are told apart from forged ones
+	if !now.Before(logo.ExpiresAt) {
+		return nil, ErrLogoURLExpired
+	}
+	return logo, nil
+}
+
+func signLogoURL(key []byte, logo *LogoURL) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%d\n%d\n%d", logo.CompanyID, logo.Version, logo.Size, logo.ExpiresAt.Unix())
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/config"
+)
+
+func newLogoURLSigner(t *testing.T, keys ...string) *LogoURLSigner {
+	signer, err := NewLogoURLSigner(&config.LogoConfig{URLKeys: keys, URLTTL: time.Hour, URLMaxTTL: 24 * time.Hour})
+	require.NoError(t, err)
+	return signer
+}
+
+func TestLogoURLSigner(t *testing.T) {
+	t.Log("Given the need to test signed logo urls are verified until they expire.")
+	signer := newLogoURLSigner(t, "a:first-secret")
+	now := time.Now()
+	companyID := uuid.New()
+
+	query, expiresAt, err := signer.Sign(LogoURL{CompanyID: companyID, Version: 2, Size: 64}, 0, now)
+	require.NoError(t, err)
+	require.WithinDuration(t, now.Add(time.Hour), expiresAt, time.Second)
+
+	logo, err := signer.Verify(companyID, query, now)
+	require.NoError(t, err)
+	require.Equal(t, 2, logo.Version)
+	require.Equal(t, 64, logo.Size)
+
+	_, err = signer.Verify(uuid.New(), query, now)
+	require.ErrorIs(t, err, ErrInvalidLogoURL)
+	query.Set("size", "512")
+	_, err = signer.Verify(companyID, query, now)
+	require.ErrorIs(t, err, ErrInvalidLogoURL)
+	query.Set("size", "64")
+	_, err = signer.Verify(companyID, query, now.Add(2*time.Hour))
+	require.ErrorIs(t, err, ErrLogoURLExpired)
+
+	_, _, err = signer.Sign(LogoURL{CompanyID: companyID}, 48*time.Hour, now)
+	require.ErrorIs(t, err, ErrLogoURLTTL)
+}
+
+func TestLogoURLSigner_Rotation(t *testing.T) {
+	t.Log("Given the need to test urls signed by old key are accepted after rotation.")
+	companyID := uuid.New()
+	now := time.Now()
+	oldQuery, _, err := newLogoURLSigner(t, "a:first-secret").Sign(LogoURL{CompanyID: companyID}, 0, now)
+	require.NoError(t, err)
+
+	rotated := newLogoURLSigner(t, "b:second-secret", "a:first-secret")
+	_, err = rotated.Verify(companyID, oldQuery, now)
+	require.NoError(t, err)
+	newQuery, _, err := rotated.Sign(LogoURL{CompanyID: companyID}, 0, now)
+	require.NoError(t, err)
+	require.Equal(t, "b", newQuery.Get("kid"))
+
+	_, err = newLogoURLSigner(t, "b:second-secret").Verify(companyID, oldQuery, now)
+	require.ErrorIs(t, err, ErrInvalidLogoURL)
+
+	_, err = NewLogoURLSigner(&config.LogoConfig{URLKeys: []string{"no-secret"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/event"
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/producer"
+	"github.com/Entetry/gocompany/internal/repository"
+)
+
+const outboxBatchSize = 100
+
+// OutboxRelay publishes company events stored in outbox
+type OutboxRelay struct {
+	outboxRepository repository.OutboxRepository
+	producer         producer.Company
+	interval         time.Duration
+}
+
+// NewOutboxRelay creates new OutboxRelay service polling outbox every interval
+func NewOutboxRelay(outboxRepository repository.OutboxRepository, companyProducer producer.Company,
+	interval time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		outboxRepository: outboxRepository,
+		producer:         companyProducer,
+		interval:         interval,
+	}
+}
+
+// Run publishes pending events until ctx is canceled
+func (o *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+	for {
+		o.relay(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay publishes batches while whole batch gets published
+func (o *OutboxRelay) relay(ctx context.Context) {
+	for ctx.Err() == nil {
+		count, err := o.outboxRepository.ProcessPending(ctx, outboxBatchSize, o.publish)
+		if err != nil {
+			log.Error(err)
+			return
+		}
+		if count < outboxBatchSize {
+			return
+		}
+	}
+}
+
+func (o *OutboxRelay) publish(ctx context.Context, e *model.OutboxEvent) error {
+	companyEvent, err := event.Decode(e.Event, e.Version, e.CompanyID, e.Payload)
+	if err != nil {
+		log.Errorf("outbox event %d can't be decoded, marking it failed: %v", e.ID, err)
+		return fmt.Errorf("%w: cannot decode outbox event %d: %v", repository.ErrUnpublishable, e.ID, err)
+	}
+	err = o.producer.Produce(ctx, companyEvent)
+	if err != nil {
+		log.Errorf("cannot publish outbox event %d( attempt %d): %v", e.ID, e.Attempts+1, err)
+	}
+	return err
+}
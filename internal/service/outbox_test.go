@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/event"
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/repository"
+)
+
+func TestOutboxRelay_PublishUndecodable(t *testing.T) {
+	t.Log("Given the need to test outbox event which can't be decoded isn't retried.")
+	relay := NewOutboxRelay(nil, nil, 0)
+	for _, e := range []*model.OutboxEvent{
+		{ID: 1, CompanyID: uuid.New(), Event: event.CREATE, Version: event.SchemaVersion, Payload: []byte("{")},
+		{ID: 2, CompanyID: uuid.New(), Event: "CompanyMerged", Version: event.SchemaVersion, Payload: []byte("{}")},
+		{ID: 3, CompanyID: uuid.New(), Event: event.CREATE, Version: event.SchemaVersion + 1, Payload: []byte("{}")},
+	} {
+		require.ErrorIs(t, relay.publish(context.Background(), e), repository.ErrUnpublishable)
+	}
+}
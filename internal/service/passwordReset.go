@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/config"
+	"github.com/Entetry/gocompany/internal/mail"
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+const (
+	passwordResetSubject = "Reset your password"
+	// passwordResetTimeout limit of emailing reset link, which outlives request
+	passwordResetTimeout = time.Minute
+	// maxPasswordResetSends limit of reset links emailed at once, links over it are dropped
+	maxPasswordResetSends = 16
+)
+
+// PasswordReset service recovering accounts of users who forgot password
+type PasswordReset struct {
+	userService    *User
+	userTokens     *UserTokens
+	sender         mail.Sender
+	refreshSession *RefreshSession
+	cfg            *config.MailConfig
+	// sending reset links emailed in background, slots bounds their number
+	sending sync.WaitGroup
+	slots   chan struct{}
+	// mu guards closed, links aren't emailed once service is closed
+	mu     sync.Mutex
+	closed bool
+	// sendCtx is canceled when service isn't closed in time
+	sendCtx    context.Context
+	cancelSend context.CancelFunc
+}
+
+// NewPasswordReset creates new PasswordReset service
+func NewPasswordReset(userService *User, userTokens *UserTokens, sender mail.Sender,
+	refreshSession *RefreshSession, cfg *config.MailConfig) *PasswordReset {
+	sendCtx, cancelSend := context.WithCancel(context.Background())
+	return &PasswordReset{
+		userService:    userService,
+		userTokens:     userTokens,
+		sender:         sender,
+		refreshSession: refreshSession,
+		cfg:            cfg,
+		slots:          make(chan struct{}, maxPasswordResetSends),
+		sendCtx:        sendCtx,
+		cancelSend:     cancelSend,
+	}
+}
+
+// Forgot emails reset link to users with email, response doesn't tell whether such user exists
+// and users are emailed at most once per resend interval. Links are emailed in background and failures
+// are only logged, so neither status nor duration of request depends on the email being registered.
+func (p *PasswordReset) Forgot(ctx context.Context, email string) error {
+	users, err := p.userService.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if !p.startSending() {
+			log.Warnf("too many password reset emails are being sent, dropping email to user %s", user.ID)
+			continue
+		}
+		go func(user *model.User) {
+			defer p.doneSending()
+			sendCtx, cancel := context.WithTimeout(p.sendCtx, passwordResetTimeout)
+			defer cancel()
+			err := p.send(sendCtx, user)
+			if err != nil {
+				log.Errorf("cannot send password reset email to user %s: %v", user.ID, err)
+			}
+		}(user)
+	}
+	return nil
+}
+
+// startSending takes sending slot, false if all slots are taken or service is closed
+func (p *PasswordReset) startSending() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	select {
+	case p.slots <- struct{}{}:
+		p.sending.Add(1)
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *PasswordReset) doneSending() {
+	<-p.slots
+	p.sending.Done()
+}
+
+// Wait waits until reset links being emailed are sent
+func (p *PasswordReset) Wait() {
+	p.sending.Wait()
+}
+
+// Close stops emailing new reset links and waits until links being emailed are sent,
+// sending is canceled once ctx is done
+func (p *PasswordReset) Close(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	sent := make(chan struct{})
+	go func() {
+		p.Wait()
+		close(sent)
+	}()
+	select {
+	case <-sent:
+		p.cancelSend()
+		return nil
+	case <-ctx.Done():
+		p.cancelSend()
+		<-sent
+		return fmt.Errorf("password reset emails weren't sent before shutdown: %w", ctx.Err())
+	}
+}
+
+func (p *PasswordReset) send(ctx context.Context, user *model.User) error {
+	recent, err := p.userTokens.IssuedWithin(ctx, user.ID, model.TokenPasswordReset, p.cfg.ResendInterval)
+	if err != nil {
+		return err
+	}
+	if recent {
+		log.Infof("password reset email to user %s was sent recently, skipping", user.ID)
+		return nil
+	}
+	token, err := p.userTokens.Issue(ctx, user.ID, model.TokenPasswordReset, p.cfg.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+	return p.sender.Send(ctx, &mail.Message{
+		To:      user.Email,
+		Subject: passwordResetSubject,
+		Body: fmt.Sprintf("Hello %s,\n\nset new password by opening the link below:\n%s%s\n\n"+
+			"The link expires in %s. If you didn't ask to reset password, ignore this email.\n",
+			user.Username, p.cfg.PasswordResetURL, url.QueryEscape(token), p.cfg.PasswordResetTTL),
+	})
+}
+
+// Reset sets new password of token user and signs user out of all sessions,
+// token is kept when password is rejected by policy so user can try another one
+func (p *PasswordReset) Reset(ctx context.Context, token, password string) error {
+	userID, err := p.userTokens.Peek(ctx, token, model.TokenPasswordReset)
+	if err != nil {
+		return err
+	}
+	user, err := p.userService.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidToken
+	}
+	err = p.userService.ValidatePassword(password, user.Username, user.Email)
+	if err != nil {
+		return err
+	}
+	// token might be used concurrently since peek
+	_, err = p.userTokens.Consume(ctx, token, model.TokenPasswordReset)
+	if err != nil {
+		return err
+	}
+	err = p.userService.UpdatePassword(ctx, user.ID, password)
+	if err != nil {
+		return err
+	}
+	return p.refreshSession.DeleteUserSessions(ctx, user.ID.String())
+}
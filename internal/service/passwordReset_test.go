@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/password"
+)
+
+// forgotPassword asks for password reset and waits until reset links are emailed
+func (f *authFixture) forgotPassword(t *testing.T, email string) {
+	require.NoError(t, f.auth.ForgotPassword(context.Background(), email))
+	f.passwordReset.Wait()
+}
+
+func TestAuth_PasswordReset(t *testing.T) {
+	t.Log("Given the need to test forgotten password is reset with single-use token and sessions are revoked.")
+	f := newAuthFixture()
+	f.mailCfg.VerificationRequired = false
+	ctx := context.Background()
+	require.NoError(t, f.auth.SignUp(ctx, "carol", "old-password", "carol@example.com"))
+	_, _, err := f.auth.SignIn(ctx, "carol", "old-password", &model.TokenParam{})
+	require.NoError(t, err)
+	require.Len(t, f.sessions.sessions, 1)
+
+	f.forgotPassword(t, "unknown@example.com")
+	require.Len(t, f.sender.messages, 1)
+	f.forgotPassword(t, "carol@example.com")
+	require.Len(t, f.sender.messages, 2)
+	require.Equal(t, passwordResetSubject, f.sender.messages[1].Subject)
+	f.forgotPassword(t, "carol@example.com")
+	require.Len(t, f.sender.messages, 2)
+	token := f.sender.lastToken(t, f.mailCfg.PasswordResetURL)
+
+	require.ErrorIs(t, f.auth.VerifyEmail(ctx, token), ErrInvalidToken)
+	require.ErrorIs(t, f.auth.ResetPassword(ctx, token, "short"), password.ErrWeakPassword)
+	require.NoError(t, f.auth.ResetPassword(ctx, token, "new-password"))
+	require.Empty(t, f.sessions.sessions)
+	require.ErrorIs(t, f.auth.ResetPassword(ctx, token, "other-password"), ErrInvalidToken)
+
+	_, _, err = f.auth.SignIn(ctx, "carol", "old-password", &model.TokenParam{})
+	require.Error(t, err)
+	_, _, err = f.auth.SignIn(ctx, "carol", "new-password", &model.TokenParam{})
+	require.NoError(t, err)
+}
+
+func TestAuth_ForgotPasswordSendFailed(t *testing.T) {
+	t.Log("Given the need to test failed reset email doesn't tell the email is registered.")
+	f := newAuthFixture()
+	ctx := context.Background()
+	require.NoError(t, f.auth.SignUp(ctx, "dave", "old-password", "dave@example.com"))
+	f.sender.err = errors.New("smtp is down")
+
+	require.NoError(t, f.auth.ForgotPassword(ctx, "dave@example.com"))
+	require.NoError(t, f.auth.ForgotPassword(ctx, "unknown@example.com"))
+	f.passwordReset.Wait()
+	require.Len(t, f.sender.messages, 1, "only verification email is sent")
+}
+
+// signUpTeam signs up users sharing email, each of them is emailed reset link
+func signUpTeam(t *testing.T, f *authFixture, count int) {
+	for i := 0; i < count; i++ {
+		require.NoError(t, f.auth.SignUp(context.Background(), fmt.Sprintf("user-%d", i), "secret-password",
+			"team@example.com"))
+	}
+}
+
+func TestPasswordReset_CloseWaitsForSends(t *testing.T) {
+	t.Log("Given the need to test closing waits for reset links and their number is bounded.")
+	f := newAuthFixture()
+	ctx := context.Background()
+	signUpTeam(t, f, maxPasswordResetSends+4)
+	verifications := len(f.sender.messages)
+	f.sender.block = make(chan struct{})
+
+	require.NoError(t, f.auth.ForgotPassword(ctx, "team@example.com"))
+	closed := make(chan error, 1)
+	go func() {
+		closed <- f.passwordReset.Close(ctx)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("closed before reset links were sent")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(f.sender.block)
+	require.NoError(t, <-closed)
+	require.Len(t, f.sender.messages, verifications+maxPasswordResetSends)
+
+	require.NoError(t, f.auth.ForgotPassword(ctx, "team@example.com"))
+	f.passwordReset.Wait()
+	require.Len(t, f.sender.messages, verifications+maxPasswordResetSends, "closed service doesn't send")
+}
+
+func TestPasswordReset_CloseTimeout(t *testing.T) {
+	t.Log("Given the need to test sending is canceled when reset links aren't sent before shutdown.")
+	f := newAuthFixture()
+	signUpTeam(t, f, 2)
+	verifications := len(f.sender.messages)
+	f.sender.block = make(chan struct{})
+
+	require.NoError(t, f.auth.ForgotPassword(context.Background(), "team@example.com"))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, f.passwordReset.Close(ctx), context.DeadlineExceeded)
+	require.Len(t, f.sender.messages, verifications)
+}
@@ -3,24 +3,31 @@ package service
 
 import (
 	"context"
+	"errors"
+
 	"github.com/Entetry/gocompany/internal/repository"
 
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
 
 	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/password"
 )
 
 // User service struct
 type User struct {
 	userRepository repository.UserRepository
+	hasher         *password.Hasher
+	policy         *password.Policy
 }
 
 // NewUserService creates new User service
-func NewUserService(userRepository repository.UserRepository) *User {
+func NewUserService(userRepository repository.UserRepository, hasher *password.Hasher,
+	policy *password.Policy) *User {
 	return &User{
-		userRepository: userRepository}
+		userRepository: userRepository,
+		hasher:         hasher,
+		policy:         policy}
 }
 
 // GetByUsername return user by its username
@@ -28,13 +35,85 @@ func (u *User) GetByUsername(ctx context.Context, username string) (*model.User,
 	return u.userRepository.GetByUsername(ctx, username)
 }
 
-// Create user
-func (u *User) Create(ctx context.Context, username, password, email string) (uuid.UUID, error) {
-	pwdHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+// GetByID return user by id, nil if there is no such user
+func (u *User) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	return u.userRepository.GetByID(ctx, id)
+}
+
+// GetByEmail return users with email
+func (u *User) GetByEmail(ctx context.Context, email string) ([]*model.User, error) {
+	return u.userRepository.GetByEmail(ctx, email)
+}
+
+// Create user with status
+func (u *User) Create(ctx context.Context, username, pwd, email, status string) (uuid.UUID, error) {
+	err := u.ValidatePassword(pwd, username, email)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	pwdHash, err := u.hasher.Hash(pwd)
 	if err != nil {
 		log.Println(err)
 		return uuid.Nil, err
 	}
 
-	return u.userRepository.Create(ctx, username, string(pwdHash), email)
+	id, err := u.userRepository.Create(ctx, username, pwdHash, email, status)
+	if errors.Is(err, repository.ErrDuplicateUsername) {
+		return uuid.Nil, ErrUsernameTaken
+	}
+	return id, err
+}
+
+// ValidatePassword returns password.PolicyError describing broken rules if new password of user is weak
+func (u *User) ValidatePassword(pwd, username, email string) error {
+	return u.policy.Check(pwd, username, email)
+}
+
+// UpdatePassword sets new password of user, password isn't validated so hashes of old passwords can be upgraded
+func (u *User) UpdatePassword(ctx context.Context, id uuid.UUID, pwd string) error {
+	pwdHash, err := u.hasher.Hash(pwd)
+	if err != nil {
+		return err
+	}
+	return u.userRepository.UpdatePassword(ctx, id, pwdHash)
+}
+
+// CheckPassword returns ErrWrongPassword if pwd doesn't match password of user,
+// hash made with outdated algorithm or parameters is replaced while plain password is known
+func (u *User) CheckPassword(ctx context.Context, user *model.User, pwd string) error {
+	ok, rehash, err := u.hasher.Verify(user.PasswordHash, pwd)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrWrongPassword
+	}
+	if rehash {
+		// user is signed in anyway, hash is upgraded on next sign in
+		err = u.UpdatePassword(ctx, user.ID, pwd)
+		if err != nil {
+			log.Errorf("cannot upgrade password hash of user %s: %v", user.ID, err)
+		}
+	}
+	return nil
+}
+
+// CheckMissingPassword spends the same time CheckPassword does when there is no user to check pwd of,
+// so sign in doesn't tell whether username exists
+func (u *User) CheckMissingPassword(pwd string) {
+	u.hasher.VerifyDummy(pwd)
+}
+
+// Update saves username, email and status of user
+func (u *User) Update(ctx context.Context, user *model.User) error {
+	err := u.userRepository.Update(ctx, user)
+	if errors.Is(err, repository.ErrDuplicateUsername) {
+		return ErrUsernameTaken
+	}
+	return err
+}
+
+// Delete deletes user
+func (u *User) Delete(ctx context.Context, id uuid.UUID) error {
+	return u.userRepository.Delete(ctx, id)
 }
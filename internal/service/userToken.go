@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/repository"
+)
+
+const userTokenLength = 32
+
+// ErrInvalidToken token is malformed, forged, expired or already used
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// UserTokens issues single-use tokens sent to users by email,
+// token is random value with its HMAC so forged tokens are rejected without db lookup
+type UserTokens struct {
+	userTokenRepository repository.UserTokenRepository
+	key                 []byte
+}
+
+// NewUserTokens creates new UserTokens service
+func NewUserTokens(userTokenRepository repository.UserTokenRepository, key string) *UserTokens {
+	return &UserTokens{userTokenRepository: userTokenRepository, key: []byte(key)}
+}
+
+// Issue creates token of user with purpose, earlier tokens with the same purpose are revoked
+func (u *UserTokens) Issue(ctx context.Context, userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	value := make([]byte, userTokenLength)
+	_, err := rand.Read(value)
+	if err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(value) + "." +
+		base64.RawURLEncoding.EncodeToString(u.sign(purpose, value))
+
+	err = u.userTokenRepository.DeleteByUser(ctx, userID, purpose)
+	if err != nil {
+		return "", err
+	}
+	err = u.userTokenRepository.Create(ctx, &model.UserToken{
+		Hash:      hashUserToken(token),
+		UserID:    userID,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Consume uses token up and returns its user
+func (u *UserTokens) Consume(ctx context.Context, token, purpose string) (uuid.UUID, error) {
+	if !u.verify(token, purpose) {
+		return uuid.Nil, ErrInvalidToken
+	}
+	userID, err := u.userTokenRepository.Consume(ctx, hashUserToken(token), purpose)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if userID == uuid.Nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+	return userID, nil
+}
+
+// Peek returns user of valid token without using it up
+func (u *UserTokens) Peek(ctx context.Context, token, purpose string) (uuid.UUID, error) {
+	if !u.verify(token, purpose) {
+		return uuid.Nil, ErrInvalidToken
+	}
+	userID, err := u.userTokenRepository.GetUserID(ctx, hashUserToken(token), purpose)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if userID == uuid.Nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+	return userID, nil
+}
+
+// IssuedWithin reports whether token of user with purpose was issued during last interval,
+// used to limit how often emails are sent to the same user
+func (u *UserTokens) IssuedWithin(ctx context.Context, userID uuid.UUID, purpose string,
+	interval time.Duration) (bool, error) {
+	issuedAt, err := u.userTokenRepository.LastCreatedAt(ctx, userID, purpose)
+	if err != nil {
+		return false, err
+	}
+	return issuedAt != nil && time.Since(*issuedAt) < interval, nil
+}
+
+// verify checks signature of token
+func (u *UserTokens) verify(token, purpose string) bool {
+	encodedValue, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	value, err := base64.RawURLEncoding.DecodeString(encodedValue)
+	if err != nil {
+		return false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	return err == nil && hmac.Equal(signature, u.sign(purpose, value))
+}
+
+// sign binds token to purpose so it can't be used for another flow
+func (u *UserTokens) sign(purpose string, value []byte) []byte {
+	mac := hmac.New(sha256.New, u.key)
+	mac.Write([]byte(purpose + "\n"))
+	mac.Write(value)
+	return mac.Sum(nil)
+}
+
+// hashUserToken only hash is stored so leaked db can't be used to take over accounts
+func hashUserToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/model"
+)
+
+func TestUserTokens_Purpose(t *testing.T) {
+	t.Log("Given the need to test tokens can't be used for another purpose.")
+	f := newAuthFixture()
+	ctx := context.Background()
+	userID := uuid.New()
+
+	token, err := f.tokens.Issue(ctx, userID, model.TokenEmailVerification, time.Hour)
+	require.NoError(t, err)
+	_, err = f.tokens.Consume(ctx, token, "other")
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	expired, err := f.tokens.Issue(ctx, userID, "other", -time.Second)
+	require.NoError(t, err)
+	_, err = f.tokens.Consume(ctx, expired, "other")
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	consumed, err := f.tokens.Consume(ctx, token, model.TokenEmailVerification)
+	require.NoError(t, err)
+	require.Equal(t, userID, consumed)
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/password"
+)
+
+func TestUser_UpdateUsernameTaken(t *testing.T) {
+	t.Log("Given the need to test username taken concurrently is reported when user is saved.")
+	f := newAuthFixture()
+	ctx := context.Background()
+	userService := NewUserService(f.users, newTestHasher(), &password.Policy{MinLength: 8, MaxLength: 64})
+	daveID, err := f.users.Create(ctx, "dave", "hash", "dave@example.com", model.UserActive)
+	require.NoError(t, err)
+	_, err = f.users.Create(ctx, "erin", "hash", "erin@example.com", model.UserActive)
+	require.NoError(t, err)
+
+	err = userService.Update(ctx, &model.User{ID: daveID, Username: "erin", Email: "dave@example.com"})
+	require.ErrorIs(t, err, ErrUsernameTaken)
+	_, err = userService.Create(ctx, "erin", "secret-password", "erin2@example.com", model.UserActive)
+	require.ErrorIs(t, err, ErrUsernameTaken)
+}
+
+func TestUser_CheckPasswordRehash(t *testing.T) {
+	t.Log("Given the need to test legacy bcrypt hashes are upgraded on successful sign in.")
+	f := newAuthFixture()
+	f.mailCfg.VerificationRequired = false
+	ctx := context.Background()
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	userID, err := f.users.Create(ctx, "frank", string(legacyHash), "frank@example.com", model.UserActive)
+	require.NoError(t, err)
+
+	_, _, err = f.auth.SignIn(ctx, "frank", "wrong-password", &model.TokenParam{})
+	require.Error(t, err)
+	user, err := f.users.GetByID(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, string(legacyHash), user.PasswordHash)
+
+	_, _, err = f.auth.SignIn(ctx, "frank", "old-password", &model.TokenParam{})
+	require.NoError(t, err)
+	user, err = f.users.GetByID(ctx, userID)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(user.PasswordHash, "$argon2id$"))
+	_, _, err = f.auth.SignIn(ctx, "frank", "old-password", &model.TokenParam{})
+	require.NoError(t, err)
+}
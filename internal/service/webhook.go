@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/Entetry/gocompany/internal/event"
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/repository"
+)
+
+const (
+	webhookSecretBytes = 32
+	maxWebhookPage     = 100
+)
+
+// ErrWebhookNotFound webhook subscription doesn't exist
+var ErrWebhookNotFound = errors.New("webhook subscription not found")
+
+// Webhook service of webhook subscriptions
+type Webhook struct {
+	webhookRepository repository.WebhookRepository
+}
+
+// NewWebhook creates new Webhook service
+func NewWebhook(webhookRepository repository.WebhookRepository) *Webhook {
+	return &Webhook{webhookRepository: webhookRepository}
+}
+
+// Create creates enabled subscription, secret is generated when empty
+func (w *Webhook) Create(ctx context.Context, subscription *model.WebhookSubscription) error {
+	err := validateEvents(subscription.Events)
+	if err != nil {
+		return err
+	}
+	if subscription.Secret == "" {
+		secret := make([]byte, webhookSecretBytes)
+		_, err = rand.Read(secret)
+		if err != nil {
+			return err
+		}
+		subscription.Secret = hex.EncodeToString(secret)
+	}
+	if subscription.Events == nil {
+		subscription.Events = []string{}
+	}
+	subscription.Enabled = true
+	return w.webhookRepository.CreateSubscription(ctx, subscription)
+}
+
+// GetAll return all subscriptions
+func (w *Webhook) GetAll(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	return w.webhookRepository.GetSubscriptions(ctx)
+}
+
+// Get return subscription by id
+func (w *Webhook) Get(ctx context.Context, id uuid.UUID) (*model.WebhookSubscription, error) {
+	subscription, err := w.webhookRepository.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return subscription, nil
+}
+
+// Update changes url, events and enabled flag of subscription, re-enabling resets its failures
+func (w *Webhook) Update(ctx context.Context, subscription *model.WebhookSubscription) error {
+	err := validateEvents(subscription.Events)
+	if err != nil {
+		return err
+	}
+	_, err = w.Get(ctx, subscription.ID)
+	if err != nil {
+		return err
+	}
+	if subscription.Events == nil {
+		subscription.Events = []string{}
+	}
+	return w.webhookRepository.UpdateSubscription(ctx, subscription)
+}
+
+// Delete deletes subscription with its deliveries
+func (w *Webhook) Delete(ctx context.Context, id uuid.UUID) error {
+	return w.webhookRepository.DeleteSubscription(ctx, id)
+}
+
+// GetDeliveries return last deliveries of subscription
+func (w *Webhook) GetDeliveries(ctx context.Context, id uuid.UUID) ([]*model.WebhookDelivery, error) {
+	_, err := w.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return w.webhookRepository.GetDeliveries(ctx, id, maxWebhookPage)
+}
+
+// GetAttempts return attempts of delivery
+func (w *Webhook) GetAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*model.WebhookAttempt, error) {
+	return w.webhookRepository.GetAttempts(ctx, deliveryID)
+}
+
+func validateEvents(events []string) error {
+	for _, eventType := range events {
+		if !event.Known(eventType) {
+			return fmt.Errorf("%w: %s", event.ErrUnknownType, eventType)
+		}
+	}
+	return nil
+}
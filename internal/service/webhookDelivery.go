@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Entetry/gocompany/internal/bus"
+	"github.com/Entetry/gocompany/internal/config"
+	"github.com/Entetry/gocompany/internal/event"
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/repository"
+)
+
+const (
+	// WebhookSignatureHeader contains SignWebhook of request body
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	// WebhookTimestampHeader unix time used in signature, receivers should reject old ones
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	// WebhookEventHeader type of delivered event
+	WebhookEventHeader = "X-Webhook-Event"
+	// WebhookIDHeader id of delivered event, same for all attempts
+	WebhookIDHeader = "X-Webhook-ID"
+
+	maxWebhookResponseBody = 64 << 10
+)
+
+// webhookPayload body of webhook request
+type webhookPayload struct {
+	ID        uuid.UUID   `json:"id"`
+	Type      string      `json:"type"`
+	CompanyID uuid.UUID   `json:"companyID"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      event.Event `json:"data"`
+}
+
+// WebhookDispatcher enqueues company events for webhook subscriptions and delivers them
+type WebhookDispatcher struct {
+	webhookRepository repository.WebhookRepository
+	client            *http.Client
+	cfg               *config.WebhookConfig
+}
+
+// NewWebhookDispatcher creates new WebhookDispatcher service
+func NewWebhookDispatcher(webhookRepository repository.WebhookRepository, cfg *config.WebhookConfig) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhookRepository: webhookRepository,
+		client:            &http.Client{Timeout: cfg.Timeout},
+		cfg:               cfg,
+	}
+}
+
+// Enqueue creates deliveries of company event message for interested subscriptions
+func (w *WebhookDispatcher) Enqueue(ctx context.Context, message *bus.Message) error {
+	e, err := event.DecodeEnvelope(&message.Envelope)
+	if err != nil {
+		return bus.Permanent(err)
+	}
+	eventID := message.ID
+	if eventID == uuid.Nil {
+		// messages published before envelopes have no id, position is stable across redeliveries
+		eventID = uuid.NewSHA1(uuid.NameSpaceOID, []byte(message.Position))
+	}
+	payload, err := json.Marshal(&webhookPayload{
+		ID:        eventID,
+		Type:      e.Type(),
+		CompanyID: e.AggregateID(),
+		Timestamp: message.Timestamp,
+		Data:      e,
+	})
+	if err != nil {
+		return bus.Permanent(err)
+	}
+	_, err = w.webhookRepository.Enqueue(ctx, eventID, e.Type(), payload)
+	return err
+}
+
+// Run delivers due webhooks until ctx is canceled
+func (w *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		w.dispatch(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch delivers batches of due webhooks concurrently while there are full batches
+func (w *WebhookDispatcher) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		// lease outlives request timeout so delivery isn't claimed twice while it's sent
+		deliveries, err := w.webhookRepository.ClaimDue(ctx, w.cfg.Concurrency, 2*w.cfg.Timeout)
+		if err != nil {
+			log.Error(err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func(delivery *model.WebhookDelivery) {
+				defer wg.Done()
+				w.deliver(ctx, delivery)
+			}(delivery)
+		}
+		wg.Wait()
+
+		if len(deliveries) < w.cfg.Concurrency {
+			return
+		}
+	}
+}
+
+func (w *WebhookDispatcher) deliver(ctx context.Context, delivery *model.WebhookDelivery) {
+	attempt := &model.WebhookAttempt{DeliveryID: delivery.ID, Attempt: delivery.Attempts + 1}
+	start := time.Now()
+	statusCode, err := w.send(ctx, delivery)
+	attempt.Duration = time.Since(start)
+	attempt.StatusCode = statusCode
+
+	delivery.Attempts = attempt.Attempt
+	delivery.LastStatusCode = statusCode
+	delivery.NextAttemptAt = time.Now()
+	switch {
+	case err == nil:
+		delivery.Status = model.WebhookDelivered
+		delivery.LastError = ""
+	case delivery.Attempts >= w.cfg.MaxAttempts:
+		attempt.Error = err.Error()
+		delivery.Status = model.WebhookFailed
+		delivery.LastError = attempt.Error
+	default:
+		attempt.Error = err.Error()
+		delivery.Status = model.WebhookPending
+		delivery.LastError = attempt.Error
+		delivery.NextAttemptAt = delivery.NextAttemptAt.Add(w.retryDelay(delivery.Attempts))
+	}
+
+	disabled, err := w.webhookRepository.RecordAttempt(ctx, delivery, attempt, w.cfg.DisableAfter)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	if disabled {
+		log.Warnf("webhook subscription %s disabled after %d failed attempts", delivery.SubscriptionID,
+			w.cfg.DisableAfter)
+	}
+}
+
+func (w *WebhookDispatcher) send(ctx context.Context, delivery *model.WebhookDelivery) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(WebhookIDHeader, delivery.EventID.String())
+	request.Header.Set(WebhookEventHeader, delivery.EventType)
+	request.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(WebhookSignatureHeader, SignWebhook(delivery.Secret, timestamp, delivery.Payload))
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if closeErr := response.Body.Close(); closeErr != nil {
+			log.Error(closeErr)
+		}
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxWebhookResponseBody))
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return response.StatusCode, fmt.Errorf("endpoint responded with %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
+
+// retryDelay doubles delay after every failed attempt
+func (w *WebhookDispatcher) retryDelay(attempts int) time.Duration {
+	delay := w.cfg.RetryDelay
+	for i := 1; i < attempts && delay < w.cfg.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > w.cfg.MaxRetryDelay {
+		delay = w.cfg.MaxRetryDelay
+	}
+	return delay
+}
+
+// SignWebhook returns "sha256=" and hex HMAC-SHA256 of "<timestamp>.<body>" with subscription secret
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
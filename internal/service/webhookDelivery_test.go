@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Entetry/gocompany/internal/bus"
+	"github.com/Entetry/gocompany/internal/config"
+	"github.com/Entetry/gocompany/internal/event"
+	"github.com/Entetry/gocompany/internal/model"
+	"github.com/Entetry/gocompany/internal/repository"
+)
+
+// fakeWebhookRepository keeps claimable deliveries and recorded attempts in memory
+type fakeWebhookRepository struct {
+	repository.WebhookRepository
+	mu       sync.Mutex
+	due      []*model.WebhookDelivery
+	enqueued [][]byte
+	attempts []*model.WebhookAttempt
+	failures int
+}
+
+func (f *fakeWebhookRepository) Enqueue(_ context.Context, _ uuid.UUID, _ string, payload []byte) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.enqueued = append(f.enqueued, payload)
+	return 1, nil
+}
+
+func (f *fakeWebhookRepository) ClaimDue(_ context.Context, limit int, _ time.Duration) ([]*model.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if limit > len(f.due) {
+		limit = len(f.due)
+	}
+	claimed := f.due[:limit]
+	f.due = f.due[limit:]
+	return claimed, nil
+}
+
+func (f *fakeWebhookRepository) RecordAttempt(_ context.Context, _ *model.WebhookDelivery,
+	attempt *model.WebhookAttempt, disableAfter int) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts = append(f.attempts, attempt)
+	if attempt.Error == "" {
+		f.failures = 0
+		return false, nil
+	}
+	f.failures++
+	return f.failures >= disableAfter, nil
+}
+
+func newWebhookConfig() *config.WebhookConfig {
+	return &config.WebhookConfig{
+		Timeout:       time.Second,
+		PollInterval:  time.Second,
+		Concurrency:   2,
+		MaxAttempts:   3,
+		RetryDelay:    time.Minute,
+		MaxRetryDelay: 90 * time.Second,
+		DisableAfter:  20,
+	}
+}
+
+func newWebhookDelivery(url string) *model.WebhookDelivery {
+	return &model.WebhookDelivery{
+		ID:             uuid.New(),
+		SubscriptionID: uuid.New(),
+		EventID:        uuid.New(),
+		EventType:      event.CREATE,
+		Payload:        []byte(`{"type":"CREATE"}`),
+		Status:         model.WebhookPending,
+		URL:            url,
+		Secret:         "secret",
+	}
+}
+
+func TestWebhookDispatcher_DeliverSigned(t *testing.T) {
+	t.Log("Given the need to test webhook is delivered with verifiable signature.")
+	var delivery *model.WebhookDelivery
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		timestamp, err := strconv.ParseInt(r.Header.Get(WebhookTimestampHeader), 10, 64)
+		require.NoError(t, err)
+		require.Equal(t, SignWebhook("secret", timestamp, body), r.Header.Get(WebhookSignatureHeader))
+		require.Equal(t, delivery.EventID.String(), r.Header.Get(WebhookIDHeader))
+		require.Equal(t, event.CREATE, r.Header.Get(WebhookEventHeader))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	delivery = newWebhookDelivery(server.URL)
+	repo := &fakeWebhookRepository{due: []*model.WebhookDelivery{delivery}}
+	NewWebhookDispatcher(repo, newWebhookConfig()).dispatch(context.Background())
+
+	require.Len(t, repo.attempts, 1)
+	require.Equal(t, http.StatusNoContent, repo.attempts[0].StatusCode)
+	require.Empty(t, repo.attempts[0].Error)
+	require.Equal(t, model.WebhookDelivered, delivery.Status)
+	require.Equal(t, 1, delivery.Attempts)
+}
+
+func TestWebhookDispatcher_Retry(t *testing.T) {
+	t.Log("Given the need to test failed webhook is rescheduled with growing delay and then failed.")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := newWebhookConfig()
+	delivery := newWebhookDelivery(server.URL)
+	repo := &fakeWebhookRepository{}
+	dispatcher := NewWebhookDispatcher(repo, cfg)
+
+	for attempt, delay := range []time.Duration{time.Minute, cfg.MaxRetryDelay} {
+		repo.due = []*model.WebhookDelivery{delivery}
+		before := time.Now()
+		dispatcher.dispatch(context.Background())
+		require.Equal(t, model.WebhookPending, delivery.Status)
+		require.Equal(t, attempt+1, delivery.Attempts)
+		require.Equal(t, http.StatusInternalServerError, delivery.LastStatusCode)
+		require.NotEmpty(t, delivery.LastError)
+		require.WithinDuration(t, before.Add(delay), delivery.NextAttemptAt, time.Second)
+	}
+
+	repo.due = []*model.WebhookDelivery{delivery}
+	dispatcher.dispatch(context.Background())
+	require.Equal(t, model.WebhookFailed, delivery.Status)
+	require.Len(t, repo.attempts, cfg.MaxAttempts)
+}
+
+func TestWebhookDispatcher_Enqueue(t *testing.T) {
+	t.Log("Given the need to test bus message is enqueued as webhook payload.")
+	repo := &fakeWebhookRepository{}
+	e := &event.CompanyCreated{CompanyID: uuid.New(), Name: "Google"}
+	envelope, err := event.NewEnvelope(e)
+	require.NoError(t, err)
+
+	err = NewWebhookDispatcher(repo, newWebhookConfig()).Enqueue(context.Background(),
+		&bus.Message{Envelope: *envelope, Position: "1"})
+	require.NoError(t, err)
+	require.Len(t, repo.enqueued, 1)
+	require.Contains(t, string(repo.enqueued[0]), envelope.ID.String())
+	require.Contains(t, string(repo.enqueued[0]), `"name":"Google"`)
+
+	err = NewWebhookDispatcher(repo, newWebhookConfig()).Enqueue(context.Background(),
+		&bus.Message{Envelope: bus.Envelope{Type: "UNKNOWN", Version: event.SchemaVersion}})
+	require.Error(t, err)
+}
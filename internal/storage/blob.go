@@ -0,0 +1,48 @@
+// Package storage provides blob stores for uploaded files
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound blob with given key doesn't exist
+	ErrNotFound = errors.New("blob not found")
+	// ErrInvalidKey key is empty or escapes store root
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// BlobStore stores blobs by slash separated keys
+type BlobStore interface {
+	// Put writes size bytes of r under key, replacing existing blob
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get opens blob for reading, returns ErrNotFound if it doesn't exist
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes blob, deleting missing blob is not an error
+	Delete(ctx context.Context, key string) error
+	// List calls fn for every blob with key starting with prefix, in no particular order
+	List(ctx context.Context, prefix string, fn func(BlobInfo) error) error
+}
+
+// BlobInfo listed blob
+type BlobInfo struct {
+	Key  string
+	Size int64
+	// ModTime time blob was last put
+	ModTime time.Time
+}
+
+// validateKey rejects keys which are not clean relative paths
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || key == ".." ||
+		strings.HasPrefix(key, "../") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// Postgres blob store keeping blobs in bytea column of blob table, meant for small files like logos
+type Postgres struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgres creates postgres blob store
+func NewPostgres(db *pgxpool.Pool) *Postgres {
+	return &Postgres{db: db}
+}
+
+// Put inserts or replaces blob
+func (p *Postgres) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	err := validateKey(key)
+	if err != nil {
+		return err
+	}
+	data := bytes.NewBuffer(make([]byte, 0, size))
+	_, err = io.Copy(data, r)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.Exec(ctx, `INSERT INTO blob (key, data) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET data = excluded.data, created_at = now()`, key, data.Bytes())
+	if err != nil {
+		return fmt.Errorf("cannot put blob: %v", err)
+	}
+	return nil
+}
+
+// Get reads whole blob
+func (p *Postgres) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	var data []byte
+	err := p.db.QueryRow(ctx, "SELECT data FROM blob WHERE key = $1", key).Scan(&data)
+	if err == pgx.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get blob: %v", err)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Delete deletes blob
+func (p *Postgres) Delete(ctx context.Context, key string) error {
+	_, err := p.db.Exec(ctx, "DELETE FROM blob WHERE key = $1", key)
+	if err != nil {
+		return fmt.Errorf("cannot delete blob: %v", err)
+	}
+	return nil
+}
+
+// List selects keys starting with prefix
+func (p *Postgres) List(ctx context.Context, prefix string, fn func(BlobInfo) error) error {
+	rows, err := p.db.Query(ctx, `SELECT key, length(data), created_at FROM blob
+		WHERE left(key, length($1)) = $1`, prefix)
+	if err != nil {
+		return fmt.Errorf("cannot list blobs: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var size int64
+		var createdAt time.Time
+		err = rows.Scan(&key, &size, &createdAt)
+		if err != nil {
+			return fmt.Errorf("cannot list blobs: %v", err)
+		}
+		err = fn(BlobInfo{Key: key, Size: size, ModTime: createdAt})
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
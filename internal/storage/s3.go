@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	s3Algorithm      = "AWS4-HMAC-SHA256"
+	s3Service        = "s3"
+	s3UnsignedBody   = "UNSIGNED-PAYLOAD"
+	s3EmptyBodyHash  = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	s3MaxErrorLength = 1 << 10
+)
+
+// S3Options connection options of S3 compatible storage
+type S3Options struct {
+	// Endpoint base url of storage, e.g. https://s3.eu-central-1.amazonaws.com or http://minio:9000
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3 blob store on top of S3 compatible object storage, objects are addressed path-style
+type S3 struct {
+	client   *http.Client
+	endpoint *url.URL
+	opts     S3Options
+}
+
+// NewS3 creates S3 blob store
+func NewS3(client *http.Client, opts S3Options) (*S3, error) {
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %v", err)
+	}
+	if opts.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is not set")
+	}
+	return &S3{client: client, endpoint: endpoint, opts: opts}, nil
+}
+
+// Put uploads object, payload isn't signed so it's streamed as is
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	request, err := s.request(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	request.ContentLength = size
+	response, err := s.do(request, s3UnsignedBody)
+	if err != nil {
+		return err
+	}
+	closeResponse(response)
+	return nil
+}
+
+// Get downloads object
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	request, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.do(request, s3EmptyBodyHash)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// Delete deletes object
+func (s *S3) Delete(ctx context.Context, key string) error {
+	request, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	response, err := s.do(request, s3EmptyBodyHash)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	closeResponse(response)
+	return nil
+}
+
+// s3ListResult page of ListObjectsV2 response
+type s3ListResult struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+}
+
+// List pages through ListObjectsV2 of bucket
+func (s *S3) List(ctx context.Context, prefix string, fn func(BlobInfo) error) error {
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		bucketURL := *s.endpoint
+		bucketURL.Path = strings.TrimSuffix(bucketURL.Path, "/") + "/" + s.opts.Bucket
+		bucketURL.RawQuery = query.Encode()
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, bucketURL.String(), nil)
+		if err != nil {
+			return err
+		}
+		response, err := s.do(request, s3EmptyBodyHash)
+		if err != nil {
+			return err
+		}
+		var page s3ListResult
+		err = xml.NewDecoder(response.Body).Decode(&page)
+		closeResponse(response)
+		if err != nil {
+			return fmt.Errorf("cannot decode s3 object list: %v", err)
+		}
+
+		for _, object := range page.Contents {
+			err = fn(BlobInfo{Key: object.Key, Size: object.Size, ModTime: object.LastModified})
+			if err != nil {
+				return err
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		query.Set("continuation-token", page.NextContinuationToken)
+	}
+}
+
+func (s *S3) request(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	err := validateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	objectURL := *s.endpoint
+	objectURL.Path = strings.TrimSuffix(objectURL.Path, "/") + "/" + s.opts.Bucket + "/" + key
+	return http.NewRequestWithContext(ctx, method, objectURL.String(), body)
+}
+
+// do signs and sends request, non 2xx responses are returned as errors
+func (s *S3) do(request *http.Request, payloadHash string) (*http.Response, error) {
+	signS3(request, payloadHash, s.opts.Region, s.opts.AccessKey, s.opts.SecretKey, time.Now())
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode >= http.StatusOK && response.StatusCode < http.StatusMultipleChoices {
+		return response, nil
+	}
+	message, _ := io.ReadAll(io.LimitReader(response.Body, s3MaxErrorLength))
+	closeResponse(response)
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	return nil, fmt.Errorf("s3 %s %s responded with %d: %s", request.Method, request.URL.Path,
+		response.StatusCode, message)
+}
+
+// closeResponse drains body so connection can be reused
+func closeResponse(response *http.Response) {
+	_, _ = io.Copy(io.Discard, response.Body)
+	err := response.Body.Close()
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// signS3 adds AWS signature version 4 authorization to request, host and all set headers are signed
+func signS3(request *http.Request, payloadHash, region, accessKey, secretKey string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := strings.Join([]string{amzDate[:8], region, s3Service, "aws4_request"}, "/")
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": request.Host}
+	if request.Host == "" {
+		headers["host"] = request.URL.Host
+	}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		s3Escape(request.URL.Path, false),
+		s3CanonicalQuery(request.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, hex.EncodeToString(canonicalHash[:])}, "\n")
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{amzDate[:8], region, s3Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	request.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, s3Escape(name, true)+"="+s3Escape(value, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// s3Escape percent-encodes everything except unreserved characters, slash is kept unless encodeSlash
+func s3Escape(s string, encodeSlash bool) string {
+	var escaped strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~':
+			escaped.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			escaped.WriteByte(b)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
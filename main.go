@@ -3,10 +3,12 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/Entetry/gocompany/internal/repository"
 	log "github.com/sirupsen/logrus"
-	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -14,21 +16,31 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/go-redis/redis/v9"
-	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/labstack/echo/v4"
 
 	echoSwagger "github.com/swaggo/echo-swagger"
 
 	_ "github.com/Entetry/gocompany/docs"
+	"github.com/Entetry/gocompany/internal/bus"
 	"github.com/Entetry/gocompany/internal/cache"
 	"github.com/Entetry/gocompany/internal/config"
 	"github.com/Entetry/gocompany/internal/consumer"
+	"github.com/Entetry/gocompany/internal/dlq"
 	"github.com/Entetry/gocompany/internal/event"
 	"github.com/Entetry/gocompany/internal/handlers"
+	"github.com/Entetry/gocompany/internal/mail"
 	"github.com/Entetry/gocompany/internal/middleware"
+	"github.com/Entetry/gocompany/internal/password"
 	"github.com/Entetry/gocompany/internal/producer"
 	"github.com/Entetry/gocompany/internal/service"
+	"github.com/Entetry/gocompany/internal/storage"
+)
+
+const (
+	shutdownTimeout = 10 * time.Second
+	// gcCommand runs logo garbage collection once instead of starting server
+	gcCommand = "gc"
 )
 
 // @title          Gotest Swagger API
@@ -49,10 +61,34 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	webhookCfg, err := config.NewWebhookConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	storageCfg, err := config.NewStorageConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	logoCfg, err := config.NewLogoConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	attachmentCfg, err := config.NewAttachmentConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	mailCfg, err := config.NewMailConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	passwordCfg, err := config.NewPasswordConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGTERM, os.Interrupt)
 
 	db, err := pgxpool.Connect(ctx, cfg.ConnectionString)
 	if err != nil {
@@ -60,89 +96,292 @@ func main() {
 	}
 	defer db.Close()
 
-	redisClient := buildRedis(cfg)
-	defer func(redisClient *redis.Client) {
-		redisErr := redisClient.Close()
-		if redisErr != nil {
-			log.Error(err)
-		}
-	}(redisClient)
+	logoRepository := repository.NewLogoRepository(db)
+	blobStore := buildBlobStore(storageCfg, db)
+	logoCollector := service.NewLogoCollector(logoRepository, blobStore, logoCfg)
+	if len(os.Args) > 1 && os.Args[1] == gcCommand {
+		collectLogos(ctx, logoCollector, os.Args[2:])
+		return
+	}
+	if logoCfg.GCInterval > 0 {
+		go logoCollector.Run(ctx, logoCfg.GCInterval)
+	}
+
+	eventBus, deadLetter, closeBus := buildBus(cfg)
+	defer closeBus()
 
 	refreshSessionRepository := repository.NewRefresh(db)
 	refreshSessionService := service.NewRefreshSession(refreshSessionRepository)
 
 	userRepository := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepository)
+	userService := service.NewUserService(userRepository, buildPasswordHasher(passwordCfg),
+		buildPasswordPolicy(passwordCfg))
+	userTokens := service.NewUserTokens(repository.NewUserTokenRepository(db), mailCfg.TokenKey)
+	mailSender := buildMailSender(mailCfg)
+	emailVerification := service.NewEmailVerification(userRepository, userTokens, mailSender, mailCfg)
+	passwordReset := service.NewPasswordReset(userService, userTokens, mailSender, refreshSessionService, mailCfg)
 
-	authService := service.NewAuthService(userService, refreshSessionService, jwtCfg)
+	authService := service.NewAuthService(userService, refreshSessionService, emailVerification, passwordReset,
+		jwtCfg)
 	authHandler := handlers.NewAuth(authService)
+	accountService := service.NewAccount(userService, refreshSessionService, emailVerification)
+	meHandler := handlers.NewMe(accountService)
 
-	redisProducer := producer.NewRedisCompanyProducer(redisClient)
-	cacheCompany := cache.NewLocalCache()
+	companyProducer := producer.NewCompanyProducer(eventBus)
+	cacheCompany := cache.NewLocalCache(cfg.NegativeCacheTTL, cfg.NegativeCacheSize)
+	defer cacheCompany.Close()
 
 	companyRepository := repository.NewCompanyRepository(db)
-	logoRepository := repository.NewLogoRepository(db)
-	companyService := service.NewCompany(companyRepository, logoRepository, cacheCompany, redisProducer)
+	companyService := service.NewCompany(companyRepository, logoRepository, blobStore, logoCfg,
+		cacheCompany, companyProducer, cfg.CacheFillBroadcast)
 	companyHandler := handlers.NewCompany(companyService)
+	logoURLSigner, err := service.NewLogoURLSigner(logoCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logoURLHandler := handlers.NewLogoURL(companyService, logoURLSigner, logoCfg.PublicURL)
 
-	go ConsumeCompanies(redisClient, cacheCompany)
+	attachmentRepository := repository.NewAttachmentRepository(db)
+	attachmentService := service.NewAttachment(attachmentRepository, blobStore, attachmentCfg)
+	if attachmentCfg.GCInterval > 0 {
+		go service.NewAttachmentCollector(attachmentRepository, blobStore, attachmentCfg).Run(ctx,
+			attachmentCfg.GCInterval)
+	}
+	attachmentHandler := handlers.NewAttachment(attachmentService)
+
+	outboxRepository := repository.NewOutboxRepository(db)
+	outboxRelay := service.NewOutboxRelay(outboxRepository, companyProducer, cfg.OutboxInterval)
+	go outboxRelay.Run(ctx)
+
+	deadLetterService := service.NewDeadLetter(deadLetter, eventBus)
+	deadLetterHandler := handlers.NewDeadLetter(deadLetterService)
+
+	webhookRepository := repository.NewWebhookRepository(db)
+	webhookService := service.NewWebhook(webhookRepository)
+	webhookHandler := handlers.NewWebhook(webhookService)
+	webhookDispatcher := service.NewWebhookDispatcher(webhookRepository, webhookCfg)
+	go eventBus.Subscribe(ctx, event.CompanyTopic, bus.SubscribeOptions{
+		Group:      fmt.Sprintf("%s.webhooks", cfg.RedisConsumerGroup),
+		Consumer:   cfg.RedisConsumerName,
+		MinIdle:    cfg.RedisConsumerMinIdle,
+		DeadLetter: deadLetter,
+	}, webhookDispatcher.Enqueue)
+	go webhookDispatcher.Run(ctx)
+
+	companyFeed := service.NewCompanyFeed(eventBus, cfg.FeedBuffer)
+	companyEventsHandler := handlers.NewCompanyEvents(companyFeed, cfg.FeedHeartbeat)
+	companySocketHandler := handlers.NewCompanySocket(companyFeed, cfg.FeedHeartbeat, cfg.SocketMaxCompanies)
+
+	companyConsumer := ConsumeCompanies(ctx, eventBus, deadLetter, cacheCompany, companyFeed)
+	healthHandler := handlers.NewHealth(companyConsumer)
 
 	e := echo.New()
 
 	e.Validator = middleware.NewCustomValidator(validator.New())
+	e.GET("/health", healthHandler.Get)
+
 	auth := e.Group("api/auth")
 	auth.POST("/refresh-tokens", authHandler.Refresh)
 	auth.POST("/sign-in", authHandler.SignIn)
 	auth.POST("/sign-up", authHandler.SignUp)
 	auth.POST("/logout", authHandler.Logout)
+	auth.POST("/verify-email", authHandler.VerifyEmail)
+	auth.POST("/resend-verification", authHandler.ResendVerification)
+	auth.POST("/password/forgot", authHandler.ForgotPassword)
+	auth.POST("/password/reset", authHandler.ResetPassword)
+
+	me := e.Group("api/me")
+	me.Use(middleware.NewJwtMiddleware(jwtCfg.AccessTokenKey))
+	me.GET("", meHandler.Get)
+	me.PATCH("", meHandler.Update)
+	me.POST("/password", meHandler.ChangePassword)
+	me.DELETE("", meHandler.Delete)
 
 	company := e.Group("api/company")
 	company.Use(middleware.NewJwtMiddleware(jwtCfg.AccessTokenKey))
 	company.POST("", companyHandler.Create)
 	company.GET("", companyHandler.GetAll)
+	company.GET("/events", companyEventsHandler.Stream)
 	company.GET("/:id", companyHandler.GetByID)
 	company.PUT("", companyHandler.Update)
 	company.DELETE("/:id", companyHandler.Delete)
 	company.POST("/logo", companyHandler.AddLogo)
 	company.GET("/logo/:id", companyHandler.GetLogoByCompanyID)
+	company.PUT("/:id/logo", companyHandler.ReplaceLogo)
+	company.DELETE("/:id/logo", companyHandler.DeleteLogo)
+	company.GET("/:id/logo/versions", companyHandler.GetLogoVersions)
+	company.GET("/:id/logo/versions/:version", companyHandler.GetLogoVersion)
+	company.POST("/:id/logo/versions/:version/rollback", companyHandler.RollbackLogo)
+	company.POST("/:id/logo/url", logoURLHandler.Issue)
+	company.POST("/:id/attachments", attachmentHandler.Create)
+	company.GET("/:id/attachments", attachmentHandler.GetAll)
+	company.GET("/:id/attachments/:attachmentID", attachmentHandler.Get)
+	company.HEAD("/:id/attachments/:attachmentID", attachmentHandler.Head)
+	company.PATCH("/:id/attachments/:attachmentID", attachmentHandler.Upload)
+	company.GET("/:id/attachments/:attachmentID/content", attachmentHandler.Download)
+
+	// signature of url authorizes request, so logos can be embedded in emails and public pages
+	e.GET("api/public/logo/:id", logoURLHandler.Serve)
+
+	// websocket handshake of browsers cannot carry Authorization header
+	e.GET("api/company/ws", companySocketHandler.Serve, middleware.NewWebSocketJwtMiddleware(jwtCfg.AccessTokenKey))
+
+	admin := e.Group("api/admin")
+	admin.Use(middleware.NewJwtMiddleware(jwtCfg.AccessTokenKey), middleware.NewAdminMiddleware(cfg.AdminUserIDs))
+	admin.GET("/dlq", deadLetterHandler.List)
+	admin.GET("/dlq/:id", deadLetterHandler.Get)
+	admin.POST("/dlq/:id/redrive", deadLetterHandler.Redrive)
+	admin.POST("/webhooks", webhookHandler.Create)
+	admin.GET("/webhooks", webhookHandler.GetAll)
+	admin.GET("/webhooks/:id", webhookHandler.Get)
+	admin.PUT("/webhooks/:id", webhookHandler.Update)
+	admin.DELETE("/webhooks/:id", webhookHandler.Delete)
+	admin.GET("/webhooks/:id/deliveries", webhookHandler.GetDeliveries)
+	admin.GET("/webhooks/deliveries/:deliveryID/attempts", webhookHandler.GetAttempts)
 
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
-	err = e.Start(fmt.Sprintf(":%d", cfg.Port))
-	if err != nil {
-		log.Fatal(err)
-	}
-	log.Info("Server started on ", cfg.Port)
+	// stopped is closed once requests are finished and reset links they started emailing are sent
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		<-sigChan
 		cancel()
-		err = e.Shutdown(ctx)
-		if err != nil {
-			log.Errorf("can't stop server gracefully %v", err)
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer shutdownCancel()
+		shutdownErr := e.Shutdown(shutdownCtx)
+		if shutdownErr != nil {
+			log.Errorf("can't stop server gracefully %v", shutdownErr)
+		}
+		shutdownErr = passwordReset.Close(shutdownCtx)
+		if shutdownErr != nil {
+			log.Error(shutdownErr)
 		}
 	}()
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
-	if err != nil {
+
+	log.Info("Server started on ", cfg.Port)
+	err = e.Start(fmt.Sprintf(":%d", cfg.Port))
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatal(err)
 	}
-	err = e.Server.Serve(listener)
+	<-stopped
+}
+
+// collectLogos runs logo garbage collection with command line flags, exits with 1 if dangling references are found
+func collectLogos(ctx context.Context, logoCollector *service.LogoCollector, args []string) {
+	flags := flag.NewFlagSet(gcCommand, flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "report orphaned blobs without deleting them")
+	_ = flags.Parse(args)
+
+	collection, err := logoCollector.Collect(ctx, *dryRun)
 	if err != nil {
 		log.Fatal(err)
 	}
+	for _, key := range collection.Orphans {
+		log.Infof("orphaned blob %s", key)
+	}
+	collection.Log()
+	if len(collection.Dangling) > 0 {
+		os.Exit(1) //nolint:gocritic
+	}
 }
 
-func ConsumeCompanies(redisClient *redis.Client, localCache *cache.LocalCache) {
-	redisCompanyConsumer := consumer.NewRedisCompanyConsumer(redisClient, fmt.Sprintf("%d000-0", time.Now().Unix()))
-	go redisCompanyConsumer.Consume(context.Background(), func(id uuid.UUID, action, name string) {
-		switch action {
-		case event.UPDATE:
-			localCache.Update(id, name)
-		case event.DELETE:
-			localCache.Delete(id)
-		default:
-			log.Error("Unknown event")
+// ConsumeCompanies keeps local cache in sync with company stream and feeds connected clients
+// until ctx is canceled
+func ConsumeCompanies(ctx context.Context, subscriber bus.Subscriber, deadLetter dlq.DeadLetter,
+	localCache *cache.LocalCache, companyFeed *service.CompanyFeed) consumer.Company {
+	companyConsumer := consumer.NewCompanyConsumer(subscriber, deadLetter, "")
+	go companyConsumer.Consume(ctx, func(position string, e event.Event) {
+		switch e := e.(type) {
+		case *event.CompanyCreated:
+			localCache.Update(e.CompanyID, e.Name)
+		case *event.CompanyUpdated:
+			localCache.Update(e.CompanyID, e.Name)
+		case *event.CompanyDeleted:
+			localCache.Delete(e.CompanyID)
 		}
+		companyFeed.Publish(position, e)
 	})
+	return companyConsumer
+}
+
+// buildBus creates event bus selected by config with its dead-letter storage
+func buildBus(cfg *config.Config) (eventBus bus.Bus, deadLetter dlq.DeadLetter, closeBus func()) {
+	if cfg.EventBus == config.MemoryBus {
+		return bus.NewMemory(cfg.MemoryBusRetention), dlq.NewMemoryDeadLetter(), func() {}
+	}
+
+	redisClient := buildRedis(cfg)
+	retention := bus.Retention{MaxLen: cfg.StreamMaxLen, MaxAge: cfg.StreamMaxAge}
+	deadLetter = dlq.NewRedisCompanyDeadLetter(redisClient, cfg.DeadLetterMaxLen, cfg.DeadLetterMaxAge)
+	return bus.NewRedis(redisClient, retention), deadLetter, func() {
+		redisErr := redisClient.Close()
+		if redisErr != nil {
+			log.Error(redisErr)
+		}
+	}
+}
+
+// buildBlobStore creates blob store of uploaded files selected by config
+func buildBlobStore(cfg *config.StorageConfig, db *pgxpool.Pool) storage.BlobStore {
+	switch cfg.BlobStore {
+	case config.PostgresStore:
+		return storage.NewPostgres(db)
+	case config.S3Store:
+		s3, err := storage.NewS3(http.DefaultClient, storage.S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		return s3
+	default:
+		return storage.NewLocal(cfg.LocalDir)
+	}
+}
+
+// buildMailSender creates sender of emails to users selected by config
+func buildMailSender(cfg *config.MailConfig) mail.Sender {
+	switch cfg.Sender {
+	case config.SMTPMail:
+		return mail.NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+	case config.FileMail:
+		return mail.NewFile(cfg.FileDir, cfg.From)
+	default:
+		return mail.NewLog(cfg.From)
+	}
+}
+
+// buildPasswordHasher creates hasher of new passwords selected by config, hashes of other algorithm are still verified
+func buildPasswordHasher(cfg *config.PasswordConfig) *password.Hasher {
+	argon2id := &password.Argon2id{Time: cfg.Argon2Time, Memory: cfg.Argon2Memory, Threads: cfg.Argon2Threads}
+	bcrypt := &password.Bcrypt{Cost: cfg.BcryptCost}
+	if cfg.Hash == config.BcryptHash {
+		return password.NewHasher(bcrypt, argon2id)
+	}
+	return password.NewHasher(argon2id, bcrypt)
+}
+
+// buildPasswordPolicy creates policy of new passwords, breached password check is enabled when list is configured
+func buildPasswordPolicy(cfg *config.PasswordConfig) *password.Policy {
+	policy := &password.Policy{
+		MinLength:      cfg.MinLength,
+		MaxLength:      cfg.MaxLength,
+		MinClasses:     cfg.MinClasses,
+		RejectUserInfo: cfg.RejectUserInfo,
+	}
+	if cfg.BreachedDir != "" {
+		breached, err := password.NewBreachedList(cfg.BreachedDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		policy.Breached = breached
+	}
+	return policy
 }
 
 func buildRedis(cfg *config.Config) *redis.Client {
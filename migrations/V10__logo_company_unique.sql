@@ -0,0 +1,2 @@
+-- company has at most one current logo, replacing it swaps the row
+CREATE UNIQUE INDEX logo_company_id_uindex ON logo (company_id);
@@ -0,0 +1,11 @@
+-- logo blobs are addressed by content hash, identical logos share one blob counted here
+CREATE TABLE logo_blob
+(
+    storage_key varchar NOT NULL PRIMARY KEY,
+    ref_count   integer NOT NULL CHECK (ref_count > 0)
+);
+
+INSERT INTO logo_blob (storage_key, ref_count)
+SELECT storage_key, count(*)
+FROM logo
+GROUP BY storage_key;
@@ -0,0 +1,10 @@
+-- every uploaded logo is kept as a version, at most one of them is current
+DROP INDEX logo_company_id_uindex;
+
+ALTER TABLE logo
+    ADD COLUMN version    integer                  NOT NULL DEFAULT 1,
+    ADD COLUMN current    boolean                  NOT NULL DEFAULT true,
+    ADD COLUMN created_at timestamp with time zone NOT NULL DEFAULT now();
+
+CREATE UNIQUE INDEX logo_company_version_uindex ON logo (company_id, version);
+CREATE UNIQUE INDEX logo_company_current_uindex ON logo (company_id) WHERE current;
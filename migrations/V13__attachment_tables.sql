@@ -0,0 +1,26 @@
+CREATE TABLE attachment
+(
+    id           uuid                     NOT NULL PRIMARY KEY,
+    company_id   uuid                     NOT NULL,
+    file_name    varchar                  NOT NULL,
+    content_type varchar                  NOT NULL,
+    size         bigint                   NOT NULL,
+    "offset"     bigint                   NOT NULL DEFAULT 0,
+    chunks       integer                  NOT NULL DEFAULT 0,
+    hash_state   bytea                    NOT NULL,
+    checksum     varchar                  NOT NULL DEFAULT '',
+    created_at   timestamp with time zone NOT NULL DEFAULT now(),
+    completed_at timestamp with time zone
+);
+CREATE INDEX attachment_company_id_index ON attachment (company_id);
+
+-- every chunk is a separate blob, offsets let downloads start in the middle of file
+CREATE TABLE attachment_chunk
+(
+    attachment_id uuid    NOT NULL REFERENCES attachment (id) ON DELETE CASCADE,
+    chunk_index   integer NOT NULL,
+    "offset"      bigint  NOT NULL,
+    size          bigint  NOT NULL,
+    storage_key   varchar NOT NULL,
+    PRIMARY KEY (attachment_id, chunk_index)
+);
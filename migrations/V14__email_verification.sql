@@ -0,0 +1,18 @@
+-- users signed up before verification was introduced are active
+ALTER TABLE users
+    ADD COLUMN status varchar NOT NULL DEFAULT 'active',
+    ALTER COLUMN email TYPE varchar(254);
+
+CREATE INDEX users_email_index ON users (lower(email));
+
+-- single-use tokens sent to users by email, only sha256 of token is stored
+CREATE TABLE user_token
+(
+    token_hash varchar                  NOT NULL PRIMARY KEY,
+    user_id    uuid                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    purpose    varchar                  NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    used_at    timestamp with time zone,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+CREATE INDEX user_token_user_id_index ON user_token (user_id, purpose);
@@ -0,0 +1,3 @@
+-- argon2id PHC strings are longer than bcrypt hashes
+ALTER TABLE users
+    ALTER COLUMN passwordHash TYPE varchar(255);
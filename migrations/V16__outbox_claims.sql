@@ -0,0 +1,5 @@
+-- events are claimed by relay before publishing, claim expires if relay dies while publishing
+ALTER TABLE outbox
+    ADD COLUMN claimed_until timestamp with time zone;
+
+CREATE INDEX outbox_company_id_index ON outbox (company_id, id);
@@ -0,0 +1,2 @@
+-- logo garbage collection finds referenced blobs from logo versions, reference counts were never read
+DROP TABLE logo_blob;
@@ -0,0 +1,9 @@
+-- attachments are deleted with their company, chunk blobs are left to attachment garbage collection
+DELETE
+FROM attachment
+WHERE company_id NOT IN (SELECT id FROM company);
+
+ALTER TABLE attachment
+    ADD CONSTRAINT attachment_company_id_fkey FOREIGN KEY (company_id) REFERENCES company (id) ON DELETE CASCADE;
+
+CREATE INDEX attachment_incomplete_index ON attachment (created_at) WHERE completed_at IS NULL;
@@ -0,0 +1,13 @@
+-- logo blobs are reference counted again, garbage collection decides orphans by the counts.
+-- Identical logos share a blob key including extension, so they share content type too.
+CREATE TABLE logo_blob
+(
+    storage_key  varchar NOT NULL PRIMARY KEY,
+    content_type varchar NOT NULL,
+    ref_count    integer NOT NULL CHECK (ref_count > 0)
+);
+
+INSERT INTO logo_blob (storage_key, content_type, ref_count)
+SELECT storage_key, min(content_type), count(*)
+FROM logo
+GROUP BY storage_key;
@@ -0,0 +1,16 @@
+-- logos can only belong to existing company, company deletion deletes them first to release their blobs
+DELETE
+FROM logo
+WHERE company_id NOT IN (SELECT id FROM company);
+
+UPDATE logo_blob
+SET ref_count = counted.ref_count
+FROM (SELECT storage_key, count(*) AS ref_count FROM logo GROUP BY storage_key) counted
+WHERE logo_blob.storage_key = counted.storage_key;
+
+DELETE
+FROM logo_blob
+WHERE storage_key NOT IN (SELECT storage_key FROM logo);
+
+ALTER TABLE logo
+    ADD CONSTRAINT logo_company_id_fkey FOREIGN KEY (company_id) REFERENCES company (id) ON DELETE CASCADE;
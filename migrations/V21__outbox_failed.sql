@@ -0,0 +1,4 @@
+-- events which can't be published are kept as failed for inspection and don't hold back later events,
+-- failed event is retried by setting failed_at back to NULL
+ALTER TABLE outbox
+    ADD COLUMN failed_at timestamp with time zone;
@@ -0,0 +1,2 @@
+-- concurrent sign ups and profile updates can't take the same username
+CREATE UNIQUE INDEX users_username_key ON users (username);
@@ -0,0 +1,11 @@
+CREATE TABLE outbox
+(
+    id              bigserial PRIMARY KEY,
+    company_id      uuid                     NOT NULL,
+    event           varchar(32)              NOT NULL,
+    name            varchar                  NOT NULL,
+    attempts        int                      NOT NULL DEFAULT 0,
+    last_error      varchar,
+    next_attempt_at timestamp with time zone NOT NULL DEFAULT now(),
+    created_at      timestamp with time zone NOT NULL DEFAULT now()
+);
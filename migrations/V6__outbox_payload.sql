@@ -0,0 +1,11 @@
+ALTER TABLE outbox
+    ADD COLUMN version int   NOT NULL DEFAULT 1,
+    ADD COLUMN payload jsonb;
+
+UPDATE outbox
+SET payload = json_build_object('name', name);
+
+ALTER TABLE outbox
+    ALTER COLUMN payload SET NOT NULL,
+    ALTER COLUMN version DROP DEFAULT,
+    DROP COLUMN name;
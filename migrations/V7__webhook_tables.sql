@@ -0,0 +1,39 @@
+CREATE TABLE webhook_subscription
+(
+    id         uuid                     NOT NULL PRIMARY KEY,
+    url        varchar                  NOT NULL,
+    secret     varchar                  NOT NULL,
+    events     varchar(32)[]            NOT NULL,
+    enabled    boolean                  NOT NULL DEFAULT true,
+    failures   int                      NOT NULL DEFAULT 0,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE TABLE webhook_delivery
+(
+    id               uuid                     NOT NULL PRIMARY KEY,
+    subscription_id  uuid                     NOT NULL REFERENCES webhook_subscription (id) ON DELETE CASCADE,
+    event_id         uuid                     NOT NULL,
+    event_type       varchar(32)              NOT NULL,
+    payload          jsonb                    NOT NULL,
+    status           varchar(16)              NOT NULL DEFAULT 'pending',
+    attempts         int                      NOT NULL DEFAULT 0,
+    next_attempt_at  timestamp with time zone NOT NULL DEFAULT now(),
+    last_status_code int,
+    last_error       varchar,
+    created_at       timestamp with time zone NOT NULL DEFAULT now(),
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX webhook_delivery_pending ON webhook_delivery (next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE webhook_attempt
+(
+    id          bigserial PRIMARY KEY,
+    delivery_id uuid                     NOT NULL REFERENCES webhook_delivery (id) ON DELETE CASCADE,
+    attempt     int                      NOT NULL,
+    status_code int,
+    error       varchar,
+    duration_ms bigint                   NOT NULL,
+    created_at  timestamp with time zone NOT NULL DEFAULT now()
+);
@@ -0,0 +1,13 @@
+ALTER TABLE logo
+    RENAME COLUMN image TO storage_key;
+
+-- logos were saved as <cwd>/data/company/<company id>.jpeg, local blob store is rooted at data/company
+UPDATE logo
+SET storage_key = regexp_replace(storage_key, '^.*/', '');
+
+CREATE TABLE blob
+(
+    key        varchar                  NOT NULL PRIMARY KEY,
+    data       bytea                    NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
@@ -0,0 +1,6 @@
+-- logos uploaded before content sniffing were always saved as jpeg
+ALTER TABLE logo
+    ADD COLUMN content_type varchar(32) NOT NULL DEFAULT 'image/jpeg';
+
+ALTER TABLE logo
+    ALTER COLUMN content_type DROP DEFAULT;